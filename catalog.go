@@ -0,0 +1,163 @@
+package strudel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// Definition represents a catalog error definition with a stable application error code
+	// Definitions can be returned as errors, in which case they are handled as errors created with New
+	// The code is used as the message key to localize the error message
+	Definition struct {
+		Code    string `json:"code"`
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		DocURL  string `json:"docUrl,omitempty"`
+	}
+
+	// Catalog represents a set of error definitions
+	Catalog struct {
+		mu   sync.RWMutex
+		defs map[string]*Definition
+	}
+)
+
+var (
+	// DefaultCatalog is the catalog used by Register and Lookup
+	DefaultCatalog = NewCatalog()
+
+	placeholderRx = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// NewCatalog returns a new empty catalog
+func NewCatalog() *Catalog {
+	return &Catalog{
+		defs: map[string]*Definition{},
+	}
+}
+
+// Register adds the specified definition to the default catalog
+func Register(d Definition) *Definition {
+	return DefaultCatalog.Register(d)
+}
+
+// Lookup returns the definition for the specified code from the default catalog
+func Lookup(code string) (*Definition, bool) {
+	return DefaultCatalog.Lookup(code)
+}
+
+// Register adds the specified definition to the catalog
+// It panics if the code is empty or has already been registered
+func (c *Catalog) Register(d Definition) *Definition {
+	if strings.TrimSpace(d.Code) == "" {
+		panic("strudel: error definition code must not be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.defs[d.Code]; ok {
+		panic(fmt.Sprintf("strudel: error definition %s already registered", d.Code))
+	}
+
+	p := &d
+	c.defs[d.Code] = p
+
+	return p
+}
+
+// Lookup returns the definition for the specified code
+func (c *Catalog) Lookup(code string) (*Definition, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	d, ok := c.defs[code]
+	return d, ok
+}
+
+// Definitions returns the catalog definitions ordered by code
+func (c *Catalog) Definitions() []Definition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ds := make([]Definition, 0, len(c.defs))
+	for _, d := range c.defs {
+		ds = append(ds, *d)
+	}
+
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].Code < ds[j].Code
+	})
+
+	return ds
+}
+
+// MarshalJSON returns the catalog definitions as a JSON array ordered by code
+func (c *Catalog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Definitions())
+}
+
+// ServeHTTP writes the catalog definitions as JSON
+func (c *Catalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := c.MarshalJSON()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// New returns a new error for the definition
+func (d *Definition) New() *Error {
+	return d.apply(newError(d.Message, nil))
+}
+
+// NewWithFields returns a new error for the definition with the specified fields
+// Any {name} placeholders in the definition message are replaced with the field values
+func (d *Definition) NewWithFields(f Fields) *Error {
+	return d.apply(newError(formatMessage(d.Message, f), nil)).
+		WithMessageKey(d.Code, f).
+		WithFields(f)
+}
+
+// Wrap returns a new error for the definition with the specified cause
+func (d *Definition) Wrap(err error) *Error {
+	return d.apply(newError(d.Message, err))
+}
+
+// Error returns the definition message
+func (d *Definition) Error() string {
+	return d.Message
+}
+
+func (d *Definition) apply(e *Error) *Error {
+	e.appCode = d.Code
+	e.docURL = d.DocURL
+	e.msgKey = d.Code
+
+	return e.WithCode(d.Status)
+}
+
+// formatMessage replaces {name} placeholders in the message with the specified field values
+// Placeholders without a matching field are left unchanged
+func formatMessage(msg string, f Fields) string {
+	if len(f) < 1 {
+		return msg
+	}
+
+	return placeholderRx.ReplaceAllStringFunc(msg, func(m string) string {
+		if v, ok := f[m[1:len(m)-1]]; ok {
+			return fmt.Sprint(v)
+		}
+
+		return m
+	})
+}
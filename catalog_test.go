@@ -0,0 +1,280 @@
+package strudel_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestCatalog_Register(t *testing.T) {
+	t.Run("should register the definition", func(t *testing.T) {
+		c := strudel.NewCatalog()
+		d := c.Register(strudel.Definition{Code: "ORDER_NOT_FOUND", Status: http.StatusNotFound, Message: "order not found"})
+
+		act, ok := c.Lookup("ORDER_NOT_FOUND")
+		if !ok {
+			t.Fatal("got false, expected true")
+		}
+
+		if act != d {
+			t.Errorf("got %v, expected %v", act, d)
+		}
+	})
+
+	tests := []struct {
+		name string
+		defs []strudel.Definition
+	}{
+		{
+			name: "should panic if the code is empty",
+			defs: []strudel.Definition{{Code: " "}},
+		},
+		{
+			name: "should panic if the code is already registered",
+			defs: []strudel.Definition{{Code: "ORDER_NOT_FOUND"}, {Code: "ORDER_NOT_FOUND"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("got nil, expected panic")
+				}
+			}()
+
+			c := strudel.NewCatalog()
+			for _, d := range tt.defs {
+				c.Register(d)
+			}
+		})
+	}
+}
+
+func TestCatalog_Lookup(t *testing.T) {
+	t.Run("should return false if the code is not registered", func(t *testing.T) {
+		if _, ok := strudel.NewCatalog().Lookup("ORDER_NOT_FOUND"); ok {
+			t.Error("got true, expected false")
+		}
+	})
+
+	t.Run("should use the default catalog", func(t *testing.T) {
+		d := strudel.Register(strudel.Definition{Code: "CATALOG_TEST_LOOKUP", Status: http.StatusNotFound})
+
+		if act, _ := strudel.Lookup("CATALOG_TEST_LOOKUP"); act != d {
+			t.Errorf("got %v, expected %v", act, d)
+		}
+	})
+}
+
+func TestCatalog_MarshalJSON(t *testing.T) {
+	c := strudel.NewCatalog()
+	c.Register(strudel.Definition{Code: "ORDER_NOT_FOUND", Status: http.StatusNotFound, Message: "order not found", DocURL: "https://example.com/errors/order-not-found"})
+	c.Register(strudel.Definition{Code: "INVALID_ORDER", Status: http.StatusUnprocessableEntity, Message: "invalid order"})
+
+	exp := `[{"code":"INVALID_ORDER","status":422,"message":"invalid order"},` +
+		`{"code":"ORDER_NOT_FOUND","status":404,"message":"order not found","docUrl":"https://example.com/errors/order-not-found"}]`
+
+	t.Run("should marshal the definitions in code order", func(t *testing.T) {
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		if act := string(b); act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+	})
+
+	t.Run("should serve the definitions", func(t *testing.T) {
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		c.ServeHTTP(rec, req)
+
+		if act, exp := rec.Header().Get("Content-Type"), "application/json"; act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+
+		if act := rec.Body.String(); act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+	})
+}
+
+func TestDefinition_New(t *testing.T) {
+	d := &strudel.Definition{
+		Code:    "ORDER_NOT_FOUND",
+		Status:  http.StatusNotFound,
+		Message: "order not found",
+		DocURL:  "https://example.com/errors/order-not-found",
+	}
+
+	cause := errors.New("cause")
+
+	tests := []struct {
+		name  string
+		err   *strudel.Error
+		cause error
+	}{
+		{
+			name: "should create the error",
+			err:  d.New(),
+		},
+		{
+			name:  "should wrap the cause",
+			err:   d.Wrap(cause),
+			cause: cause,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := []interface{}{tt.err.Message(), tt.err.Code(), tt.err.AppCode(), tt.err.DocURL(), tt.err.Unwrap()}
+			exp := []interface{}{d.Message, d.Status, d.Code, d.DocURL, tt.cause}
+
+			if !reflect.DeepEqual(act, exp) {
+				t.Errorf("got %v, expected %v", act, exp)
+			}
+		})
+	}
+}
+
+func TestDefinition_NewWithFields(t *testing.T) {
+	d := &strudel.Definition{
+		Code:    "ORDER_NOT_FOUND",
+		Status:  http.StatusNotFound,
+		Message: "order {orderId} not found in {store}",
+	}
+
+	tests := []struct {
+		name   string
+		fields strudel.Fields
+		msg    string
+	}{
+		{
+			name: "should not replace placeholders without fields",
+			msg:  "order {orderId} not found in {store}",
+		},
+		{
+			name:   "should replace placeholders with field values",
+			fields: strudel.Fields{"orderId": 123, "store": "main"},
+			msg:    "order 123 not found in main",
+		},
+		{
+			name:   "should not replace unknown placeholders",
+			fields: strudel.Fields{"orderId": "abc"},
+			msg:    "order abc not found in {store}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.NewWithFields(tt.fields)
+
+			if act := err.Message(); act != tt.msg {
+				t.Errorf("got %s, expected %s", act, tt.msg)
+			}
+
+			if act := err.AppCode(); act != d.Code {
+				t.Errorf("got %s, expected %s", act, d.Code)
+			}
+
+			exp := strudel.Fields{}
+			for k, v := range tt.fields {
+				exp[k] = v
+			}
+
+			if act := err.Fields(); !reflect.DeepEqual(act, exp) {
+				t.Errorf("got %v, expected %v", act, exp)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	d := &strudel.Definition{Code: "ORDER_NOT_FOUND", Status: http.StatusNotFound}
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		exp    bool
+	}{
+		{
+			name:   "should match the definition",
+			err:    fmt.Errorf("context: %w", d.New()),
+			target: d,
+			exp:    true,
+		},
+		{
+			name:   "should match errors with the same code",
+			err:    d.New(),
+			target: (&strudel.Definition{Code: "ORDER_NOT_FOUND"}).New(),
+			exp:    true,
+		},
+		{
+			name:   "should not match errors with different codes",
+			err:    d.New(),
+			target: &strudel.Definition{Code: "ORDER_CONFLICT"},
+		},
+		{
+			name:   "should not match errors without codes",
+			err:    strudel.NewError("error"),
+			target: strudel.NewError("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if act := errors.Is(tt.err, tt.target); act != tt.exp {
+				t.Errorf("got %t, expected %t", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestAsError(t *testing.T) {
+	d := &strudel.Definition{Code: "ORDER_NOT_FOUND", Status: http.StatusNotFound}
+	se := strudel.NewError("error")
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+		ok   bool
+	}{
+		{
+			name: "should return the error",
+			err:  fmt.Errorf("context: %w", se),
+			ok:   true,
+		},
+		{
+			name: "should return an error for definitions",
+			err:  fmt.Errorf("context: %w", d),
+			code: "ORDER_NOT_FOUND",
+			ok:   true,
+		},
+		{
+			name: "should return false for other errors",
+			err:  errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, ok := strudel.AsError(tt.err)
+			if ok != tt.ok {
+				t.Fatalf("got %t, expected %t", ok, tt.ok)
+			}
+
+			if ok && act.AppCode() != tt.code {
+				t.Errorf("got %s, expected %s", act.AppCode(), tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+// Package chistrudel provides chi route template tracking for strudel middleware
+package chistrudel
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stevecallear/strudel"
+)
+
+// Middleware is a chi middleware function that sets the matched route pattern as the request route
+// The pattern is set once the request has been handled, as chi resolves it while routing
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		if rc := chi.RouteContext(r.Context()); rc != nil {
+			if p := rc.RoutePattern(); p != "" {
+				strudel.SetRoute(r, p)
+			}
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package chistrudel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stevecallear/janice"
+
+	"github.com/stevecallear/strudel"
+	"github.com/stevecallear/strudel/chistrudel"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		route  string
+		ok     bool
+	}{
+		{
+			name:   "should set the route pattern",
+			target: "/orders/abc",
+			route:  "/orders/{id}",
+			ok:     true,
+		},
+		{
+			name:   "should set nested route patterns",
+			target: "/orders/abc/items/1",
+			route:  "/orders/{id}/items/{item}",
+			ok:     true,
+		},
+		{
+			name:   "should not set the route if no route matches",
+			target: "/unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Use(chistrudel.Middleware)
+			r.Get("/orders/{id}", func(http.ResponseWriter, *http.Request) {})
+			r.Route("/orders/{id}/items", func(r chi.Router) {
+				r.Get("/{item}", func(http.ResponseWriter, *http.Request) {})
+			})
+
+			var route string
+			var ok bool
+			h := janice.New(strudel.RequestTracking).Then(func(w http.ResponseWriter, req *http.Request) error {
+				r.ServeHTTP(w, req)
+				route, ok = strudel.GetRoute(req)
+				return nil
+			})
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+
+			if route != tt.route || ok != tt.ok {
+				t.Errorf("got %s, %t, expected %s, %t", route, ok, tt.route, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	catalog struct {
+		Package string       `json:"package" yaml:"package"`
+		Errors  []definition `json:"errors" yaml:"errors"`
+	}
+
+	definition struct {
+		Name        string  `json:"name" yaml:"name"`
+		Code        string  `json:"code" yaml:"code"`
+		Status      int     `json:"status" yaml:"status"`
+		Message     string  `json:"message" yaml:"message"`
+		Description string  `json:"description" yaml:"description"`
+		DocURL      string  `json:"docUrl" yaml:"docUrl"`
+		Fields      []field `json:"fields" yaml:"fields"`
+	}
+
+	field struct {
+		Name        string `json:"name" yaml:"name"`
+		Type        string `json:"type" yaml:"type"`
+		Description string `json:"description" yaml:"description"`
+	}
+)
+
+var (
+	placeholderRx = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	fieldTypes = map[string]bool{
+		"string":  true,
+		"int":     true,
+		"int64":   true,
+		"float64": true,
+		"bool":    true,
+	}
+)
+
+// readCatalog reads the catalog file, using the file extension to determine the format
+func readCatalog(path string) (*catalog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(catalog)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	default:
+		return nil, fmt.Errorf("%s: unsupported catalog extension %q", path, ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// validate validates the catalog and applies default field types
+// Definitions are sorted by code so that the generated output is deterministic
+func (c *catalog) validate() error {
+	if !token.IsIdentifier(c.Package) {
+		return fmt.Errorf("invalid package name %q", c.Package)
+	}
+
+	names, codes := map[string]bool{}, map[string]bool{}
+	for i := range c.Errors {
+		d := &c.Errors[i]
+
+		if !token.IsIdentifier(d.Name) || !token.IsExported(d.Name) {
+			return fmt.Errorf("invalid error name %q, expected exported identifier", d.Name)
+		}
+
+		if names[d.Name] {
+			return fmt.Errorf("duplicate error name %s", d.Name)
+		}
+		names[d.Name] = true
+
+		if strings.TrimSpace(d.Code) == "" {
+			return fmt.Errorf("%s: code must not be empty", d.Name)
+		}
+
+		if codes[d.Code] {
+			return fmt.Errorf("%s: duplicate error code %s", d.Name, d.Code)
+		}
+		codes[d.Code] = true
+
+		if d.Status < 400 || d.Status > 599 {
+			return fmt.Errorf("%s: invalid status %d, expected 4xx or 5xx", d.Name, d.Status)
+		}
+
+		fields := map[string]bool{}
+		for j := range d.Fields {
+			f := &d.Fields[j]
+
+			if !token.IsIdentifier(f.Name) && !token.IsKeyword(f.Name) {
+				return fmt.Errorf("%s: invalid field name %q", d.Name, f.Name)
+			}
+
+			if fields[f.Name] {
+				return fmt.Errorf("%s: duplicate field %s", d.Name, f.Name)
+			}
+			fields[f.Name] = true
+
+			if f.Type == "" {
+				f.Type = "string"
+			}
+
+			if !fieldTypes[f.Type] {
+				return fmt.Errorf("%s: unsupported type %s for field %s", d.Name, f.Type, f.Name)
+			}
+		}
+
+		for _, m := range placeholderRx.FindAllStringSubmatch(d.Message, -1) {
+			if !fields[m[1]] {
+				return fmt.Errorf("%s: message placeholder {%s} is not a field", d.Name, m[1])
+			}
+		}
+	}
+
+	sort.Slice(c.Errors, func(i, j int) bool {
+		return c.Errors[i].Code < c.Errors[j].Code
+	})
+
+	return nil
+}
+
+// StatusText returns the status code and text
+func (d definition) StatusText() string {
+	return fmt.Sprintf("%d %s", d.Status, http.StatusText(d.Status))
+}
+
+// ParamName returns the constructor parameter name for the field
+func (f field) ParamName() string {
+	if token.IsKeyword(f.Name) || f.Name == "strudel" {
+		return f.Name + "_"
+	}
+
+	return f.Name
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by strudel-gen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import "github.com/stevecallear/strudel"
+
+{{- if .Errors }}
+
+var (
+{{- range .Errors }}
+	// {{ .Name }} is the {{ .Code }} error definition
+	// It can be used with errors.Is to match errors created by Err{{ .Name }}
+	{{ .Name }} = strudel.Register(strudel.Definition{
+		Code:    {{ quote .Code }},
+		Status:  {{ .Status }},
+		Message: {{ quote .Message }},
+		{{- if .DocURL }}
+		DocURL:  {{ quote .DocURL }},
+		{{- end }}
+	})
+{{ end -}}
+)
+{{- end }}
+{{ range .Errors }}
+// Err{{ .Name }} returns a new {{ .Code }} error
+{{- if .Description }}
+// {{ .Description }}
+{{- end }}
+func Err{{ .Name }}({{ range $i, $f := .Fields }}{{ if $i }}, {{ end }}{{ $f.ParamName }} {{ $f.Type }}{{ end }}) *strudel.Error {
+	{{- if .Fields }}
+	return {{ .Name }}.NewWithFields(strudel.Fields{
+		{{- range .Fields }}
+		{{ quote .Name }}: {{ .ParamName }},
+		{{- end }}
+	})
+	{{- else }}
+	return {{ .Name }}.New()
+	{{- end }}
+}
+{{ end -}}
+`))
+
+// generateGo returns the formatted go source for the catalog
+func generateGo(c *catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, c); err != nil {
+		return nil, err
+	}
+
+	b, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w", err)
+	}
+
+	return b, nil
+}
+
+// generateMarkdown returns the markdown error reference for the catalog
+func generateMarkdown(c *catalog) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "# Error reference")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "| Code | Status | Message |")
+	fmt.Fprintln(&buf, "| --- | --- | --- |")
+
+	for _, d := range c.Errors {
+		fmt.Fprintf(&buf, "| [`%s`](#%s) | %s | %s |\n", d.Code, anchor(d.Code), d.StatusText(), escapeCell(d.Message))
+	}
+
+	for _, d := range c.Errors {
+		fmt.Fprintln(&buf)
+		fmt.Fprintf(&buf, "## %s\n", d.Code)
+		fmt.Fprintln(&buf)
+
+		if d.Description != "" {
+			fmt.Fprintln(&buf, d.Description)
+			fmt.Fprintln(&buf)
+		}
+
+		fmt.Fprintf(&buf, "- Status: `%s`\n", d.StatusText())
+		fmt.Fprintf(&buf, "- Message: `%s`\n", d.Message)
+		fmt.Fprintf(&buf, "- Constructor: `Err%s`\n", d.Name)
+
+		if d.DocURL != "" {
+			fmt.Fprintf(&buf, "- Documentation: %s\n", d.DocURL)
+		}
+
+		if len(d.Fields) < 1 {
+			continue
+		}
+
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, "| Field | Type | Description |")
+		fmt.Fprintln(&buf, "| --- | --- | --- |")
+
+		for _, f := range d.Fields {
+			fmt.Fprintf(&buf, "| `%s` | `%s` | %s |\n", f.Name, f.Type, escapeCell(f.Description))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// anchor returns the markdown heading anchor for the specified code
+func anchor(code string) string {
+	return strings.ToLower(code)
+}
+
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,66 @@
+// Command strudel-gen generates typed error constructors and an error reference from an error catalog file
+//
+// Usage:
+//
+//	strudel-gen -in errors.yaml -out errors_gen.go [-doc ERRORS.md] [-pkg name]
+//
+// The catalog file can be YAML or JSON, and must have a .yaml, .yml or .json extension.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "strudel-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("strudel-gen", flag.ContinueOnError)
+
+	in := fs.String("in", "", "the catalog file")
+	out := fs.String("out", "", "the generated go file")
+	doc := fs.String("doc", "", "the generated markdown reference file")
+	pkg := fs.String("pkg", "", "the generated package name, overriding the catalog package")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *in == "" || *out == "" {
+		return fmt.Errorf("-in and -out must be specified")
+	}
+
+	c, err := readCatalog(*in)
+	if err != nil {
+		return err
+	}
+
+	if *pkg != "" {
+		c.Package = *pkg
+	}
+
+	if err = c.validate(); err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	b, err := generateGo(c)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(*out, b, 0o644); err != nil {
+		return err
+	}
+
+	if *doc == "" {
+		return nil
+	}
+
+	return os.WriteFile(*doc, generateMarkdown(c), 0o644)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{
+			name: "should generate from yaml",
+			in:   "orders.yaml",
+		},
+		{
+			name: "should generate from json",
+			in:   "orders.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out, doc := filepath.Join(dir, "orders.go"), filepath.Join(dir, "orders.md")
+
+			err := run([]string{"-in", filepath.Join("testdata", tt.in), "-out", out, "-doc", doc})
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			assertGolden(t, out, filepath.Join("testdata", "orders.go.golden"))
+			assertGolden(t, doc, filepath.Join("testdata", "orders.md.golden"))
+		})
+	}
+
+	t.Run("should override the package name", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "orders.go")
+
+		err := run([]string{"-in", filepath.Join("testdata", "orders.yaml"), "-out", out, "-pkg", "errs"})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		if !strings.Contains(string(b), "\npackage errs\n") {
+			t.Errorf("got %s, expected package errs", b)
+		}
+	})
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		catalog string
+		err     string
+	}{
+		{
+			name: "should return an error if the files are not specified",
+			args: []string{},
+			err:  "-in and -out must be specified",
+		},
+		{
+			name:    "should return an error for unsupported extensions",
+			args:    []string{"-in", "catalog.txt"},
+			catalog: "package: orders",
+			err:     "unsupported catalog extension",
+		},
+		{
+			name:    "should return an error for unknown keys",
+			catalog: "package: orders\nunknown: true",
+			err:     "field unknown not found",
+		},
+		{
+			name:    "should return an error for invalid package names",
+			catalog: "package: 1orders",
+			err:     `invalid package name "1orders"`,
+		},
+		{
+			name:    "should return an error for unexported names",
+			catalog: "package: orders\nerrors:\n  - name: orderNotFound",
+			err:     `invalid error name "orderNotFound"`,
+		},
+		{
+			name:    "should return an error for duplicate names",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400}\n  - {name: A, code: B, status: 400}",
+			err:     "duplicate error name A",
+		},
+		{
+			name:    "should return an error for empty codes",
+			catalog: "package: orders\nerrors:\n  - {name: A, status: 400}",
+			err:     "A: code must not be empty",
+		},
+		{
+			name:    "should return an error for duplicate codes",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400}\n  - {name: B, code: A, status: 400}",
+			err:     "B: duplicate error code A",
+		},
+		{
+			name:    "should return an error for invalid status codes",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 200}",
+			err:     "A: invalid status 200",
+		},
+		{
+			name:    "should return an error for invalid field names",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400, fields: [{name: order-id}]}",
+			err:     `A: invalid field name "order-id"`,
+		},
+		{
+			name:    "should return an error for duplicate fields",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400, fields: [{name: id}, {name: id}]}",
+			err:     "A: duplicate field id",
+		},
+		{
+			name:    "should return an error for unsupported field types",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400, fields: [{name: id, type: uuid}]}",
+			err:     "A: unsupported type uuid for field id",
+		},
+		{
+			name:    "should return an error for undeclared placeholders",
+			catalog: "package: orders\nerrors:\n  - {name: A, code: A, status: 400, message: 'order {id} not found'}",
+			err:     "A: message placeholder {id} is not a field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			args := tt.args
+			if args == nil {
+				args = []string{"-in", "catalog.yaml"}
+			}
+
+			for i, a := range args {
+				if strings.HasPrefix(a, "catalog.") {
+					args[i] = filepath.Join(dir, a)
+					if err := os.WriteFile(args[i], []byte(tt.catalog), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			if len(args) > 0 {
+				args = append(args, "-out", filepath.Join(dir, "out.go"))
+			}
+
+			err := run(args)
+			if err == nil {
+				t.Fatal("got nil, expected error")
+			}
+
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, expected %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func assertGolden(t *testing.T, path, golden string) {
+	t.Helper()
+
+	act, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if *update {
+		if err = os.WriteFile(golden, act, 0o644); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+
+	exp, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if string(act) != string(exp) {
+		t.Errorf("got:\n%s\nexpected:\n%s", act, exp)
+	}
+}
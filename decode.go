@@ -0,0 +1,165 @@
+package strudel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type (
+	// DecodeOption represents a request decoding option
+	DecodeOption func(*decodeOptions)
+
+	decodeOptions struct {
+		maxBodySize           int64
+		disallowUnknownFields bool
+	}
+)
+
+// MaxBodySize is the default maximum request body size in bytes used by DecodeJSON
+var MaxBodySize int64 = 1 << 20
+
+// WithMaxBodySize configures the maximum request body size in bytes
+// MaxBodySize is used if the size is zero or negative
+func WithMaxBodySize(n int64) DecodeOption {
+	return func(o *decodeOptions) {
+		o.maxBodySize = n
+	}
+}
+
+// WithDisallowUnknownFields configures decoding to fail if the body contains unknown fields
+func WithDisallowUnknownFields() DecodeOption {
+	return func(o *decodeOptions) {
+		o.disallowUnknownFields = true
+	}
+}
+
+// DecodeJSON decodes the JSON request body into the specified value
+// An error with the appropriate status code is returned if the request content type is not JSON (415),
+// the body exceeds the maximum size (413) or the body is empty, null or invalid (400).
+func DecodeJSON(r *http.Request, v interface{}, opts ...DecodeOption) error {
+	o := decodeOptions{maxBodySize: MaxBodySize}
+	for _, fn := range opts {
+		fn(&o)
+	}
+
+	if o.maxBodySize <= 0 {
+		o.maxBodySize = MaxBodySize
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if !isJSONContentType(ct) {
+		return newError("request content type must be application/json", nil).
+			WithCode(http.StatusUnsupportedMediaType).
+			WithField("contentType", ct)
+	}
+
+	if r.Body == nil {
+		return errEmptyBody()
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r.Body, o.maxBodySize+1))
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return errBodyTooLarge(err, mbe.Limit)
+		}
+
+		return newError("request body could not be read", err).
+			WithCode(http.StatusBadRequest)
+	}
+
+	if int64(len(b)) > o.maxBodySize {
+		return errBodyTooLarge(nil, o.maxBodySize)
+	}
+
+	if tb := bytes.TrimSpace(b); len(tb) < 1 || bytes.Equal(tb, []byte("null")) {
+		return errEmptyBody()
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if o.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err = dec.Decode(v); err != nil {
+		return decodeError(err, len(b))
+	}
+
+	off := dec.InputOffset()
+	if err = dec.Decode(&struct{}{}); err != io.EOF {
+		return newError("request body must contain a single JSON value", nil).
+			WithCode(http.StatusBadRequest).
+			WithField("offset", off)
+	}
+
+	return nil
+}
+
+// decodeError returns a bad request error for the specified JSON decoding error
+func decodeError(err error, n int) error {
+	var (
+		se *json.SyntaxError
+		te *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &se):
+		return newError("request body contains malformed JSON", err).
+			WithCode(http.StatusBadRequest).
+			WithField("offset", se.Offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return newError("request body contains malformed JSON", err).
+			WithCode(http.StatusBadRequest).
+			WithField("offset", int64(n))
+
+	case errors.As(err, &te):
+		e := newError("request body contains an invalid value", err).
+			WithCode(http.StatusBadRequest).
+			WithField("offset", te.Offset).
+			WithField("expected", te.Type.String())
+
+		if te.Field != "" {
+			e.WithField("field", te.Field)
+		}
+
+		return e
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		f := strings.TrimPrefix(err.Error(), "json: unknown field ")
+
+		return newError("request body contains an unknown field", err).
+			WithCode(http.StatusBadRequest).
+			WithField("field", strings.Trim(f, `"`))
+
+	default:
+		// invalid unmarshal targets are programming errors rather than client errors
+		return err
+	}
+}
+
+func errEmptyBody() *Error {
+	return newError("request body must not be empty", nil).
+		WithCode(http.StatusBadRequest)
+}
+
+func errBodyTooLarge(cause error, limit int64) *Error {
+	return newError("request body is too large", cause).
+		WithCode(http.StatusRequestEntityTooLarge).
+		WithField("limit", limit)
+}
+
+// isJSONContentType returns true if the content type is application/json or a +json suffix type
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	return mt == "application/json" || strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json")
+}
@@ -0,0 +1,216 @@
+package strudel_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+type decodeOrder struct {
+	ID       string `json:"id"`
+	Quantity int    `json:"quantity"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []strudel.DecodeOption
+		exp         decodeOrder
+		code        int
+		fields      strudel.Fields
+	}{
+		{
+			name:        "should decode the body",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id":"abc","quantity":2}`,
+			exp:         decodeOrder{ID: "abc", Quantity: 2},
+		},
+		{
+			name:        "should accept json suffix content types",
+			contentType: "application/vnd.order+json",
+			body:        `{"id":"abc","unknown":true}`,
+			exp:         decodeOrder{ID: "abc"},
+		},
+		{
+			name:        "should return an error if the content type is missing",
+			contentType: "",
+			body:        `{}`,
+			code:        http.StatusUnsupportedMediaType,
+			fields:      strudel.Fields{"contentType": ""},
+		},
+		{
+			name:        "should return an error if the content type is not json",
+			contentType: "text/plain",
+			body:        `{}`,
+			code:        http.StatusUnsupportedMediaType,
+			fields:      strudel.Fields{"contentType": "text/plain"},
+		},
+		{
+			name:        "should return an error if the body is empty",
+			contentType: "application/json",
+			body:        " \n",
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{},
+		},
+		{
+			name:        "should return an error if the body is null",
+			contentType: "application/json",
+			body:        " null\n",
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{},
+		},
+		{
+			name:        "should return an error if the body is too large",
+			contentType: "application/json",
+			body:        `{"id":"abcdef"}`,
+			opts:        []strudel.DecodeOption{strudel.WithMaxBodySize(10)},
+			code:        http.StatusRequestEntityTooLarge,
+			fields:      strudel.Fields{"limit": int64(10)},
+		},
+		{
+			name:        "should use the default size if the configured size is not positive",
+			contentType: "application/json",
+			body:        `{"id":"abcdef"}`,
+			opts:        []strudel.DecodeOption{strudel.WithMaxBodySize(0)},
+			exp:         decodeOrder{ID: "abcdef"},
+		},
+		{
+			name:        "should return an error for malformed json",
+			contentType: "application/json",
+			body:        `{"id":"abc",}`,
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{"offset": int64(13)},
+		},
+		{
+			name:        "should return an error for truncated json",
+			contentType: "application/json",
+			body:        `{"id":"abc"`,
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{"offset": int64(11)},
+		},
+		{
+			name:        "should return an error for type mismatches",
+			contentType: "application/json",
+			body:        `{"id":"abc","quantity":"2"}`,
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{"field": "quantity", "offset": int64(26), "expected": "int"},
+		},
+		{
+			name:        "should return an error for unknown fields",
+			contentType: "application/json",
+			body:        `{"id":"abc","unknown":true}`,
+			opts:        []strudel.DecodeOption{strudel.WithDisallowUnknownFields()},
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{"field": "unknown"},
+		},
+		{
+			name:        "should return an error for multiple values",
+			contentType: "application/json",
+			body:        `{"id":"abc"}{"id":"def"}`,
+			code:        http.StatusBadRequest,
+			fields:      strudel.Fields{"offset": int64(12)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var act decodeOrder
+			err := strudel.DecodeJSON(r, &act, tt.opts...)
+
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatalf("got %v, expected nil", err)
+				}
+
+				if act != tt.exp {
+					t.Errorf("got %v, expected %v", act, tt.exp)
+				}
+				return
+			}
+
+			se, ok := err.(*strudel.Error)
+			if !ok {
+				t.Fatalf("got %T, expected *strudel.Error", err)
+			}
+
+			if act := se.Code(); act != tt.code {
+				t.Errorf("got %d, expected %d", act, tt.code)
+			}
+
+			if act := se.Fields(); !reflect.DeepEqual(act, tt.fields) {
+				t.Errorf("got %v, expected %v", act, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	t.Run("should map max bytes errors to request entity too large", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"abcdef"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Body = http.MaxBytesReader(rec, r.Body, 5)
+
+		var v decodeOrder
+		err := strudel.DecodeJSON(r, &v)
+
+		var se *strudel.Error
+		if !errors.As(err, &se) {
+			t.Fatalf("got %T, expected *strudel.Error", err)
+		}
+
+		if act, exp := se.Code(), http.StatusRequestEntityTooLarge; act != exp {
+			t.Errorf("got %d, expected %d", act, exp)
+		}
+	})
+
+	t.Run("should map read errors to bad request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(errReader{}))
+		r.Header.Set("Content-Type", "application/json")
+
+		var v decodeOrder
+		err := strudel.DecodeJSON(r, &v)
+
+		var se *strudel.Error
+		if !errors.As(err, &se) {
+			t.Fatalf("got %T, expected *strudel.Error", err)
+		}
+
+		if act, exp := se.Code(), http.StatusBadRequest; act != exp {
+			t.Errorf("got %d, expected %d", act, exp)
+		}
+	})
+
+	t.Run("should not map invalid targets to client errors", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+
+		err := strudel.DecodeJSON(r, decodeOrder{})
+		if err == nil {
+			t.Fatal("got nil, expected error")
+		}
+
+		if _, ok := err.(*strudel.Error); ok {
+			t.Errorf("got %v, expected non strudel error", err)
+		}
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("error")
+}
@@ -0,0 +1,350 @@
+package strudel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gamegos/jsend"
+)
+
+type (
+	// ErrorEncoder represents an error response encoder
+	ErrorEncoder interface {
+		// ContentType returns the media type written by the encoder
+		ContentType() string
+
+		// Encode writes the specified error to the response
+		Encode(w http.ResponseWriter, r *http.Request, err *Error) error
+	}
+
+	// DataEncoder represents an error encoder that can also write success and fail responses
+	DataEncoder interface {
+		ErrorEncoder
+
+		// EncodeData writes the specified status and data to the response
+		EncodeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) error
+	}
+
+	// EncodeFunc represents an error encoding function
+	EncodeFunc func(w http.ResponseWriter, r *http.Request, err *Error) error
+
+	// EncodeDataFunc represents a data encoding function
+	EncodeDataFunc func(w http.ResponseWriter, r *http.Request, status int, data interface{}) error
+
+	encoder struct {
+		contentType string
+		fn          EncodeFunc
+	}
+
+	dataEncoder struct {
+		*encoder
+		dataFn EncodeDataFunc
+	}
+
+	mediaRange struct {
+		typ     string
+		subtype string
+		q       float64
+	}
+)
+
+var (
+	// JSend is the jsend encoder
+	// It implements DataEncoder and is used to write success and fail responses by default
+	JSend = NewDataEncoder("application/json", encodeJSend, encodeJSendData)
+
+	// Problem is the RFC 7807 problem details error encoder
+	Problem = NewErrorEncoder("application/problem+json", encodeProblem)
+
+	// Text is the plain text error encoder
+	Text = NewErrorEncoder("text/plain", encodeText)
+
+	encoders   = []ErrorEncoder{JSend, Problem, Text}
+	encodersMu sync.RWMutex
+)
+
+// NewErrorEncoder returns a new error encoder for the specified content type and function
+func NewErrorEncoder(contentType string, fn EncodeFunc) ErrorEncoder {
+	return &encoder{
+		contentType: contentType,
+		fn:          fn,
+	}
+}
+
+// NewDataEncoder returns a new encoder for the specified content type, error and data functions
+func NewDataEncoder(contentType string, fn EncodeFunc, dataFn EncodeDataFunc) DataEncoder {
+	return &dataEncoder{
+		encoder: &encoder{
+			contentType: contentType,
+			fn:          fn,
+		},
+		dataFn: dataFn,
+	}
+}
+
+// RegisterEncoder registers the specified encoder for content negotiation
+// Any existing encoder with the same content type is replaced
+func RegisterEncoder(e ErrorEncoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	ct := mediaType(e.ContentType())
+	for i, ee := range encoders {
+		if mediaType(ee.ContentType()) == ct {
+			encoders[i] = e
+			return
+		}
+	}
+
+	encoders = append(encoders, e)
+}
+
+// NegotiateEncoder returns the registered encoder that best matches the request Accept header
+// The default encoder is returned if the header is empty or no registered encoder is acceptable
+func NegotiateEncoder(r *http.Request, def ErrorEncoder) ErrorEncoder {
+	return negotiateEncoder(r, def, registeredEncoders())
+}
+
+// ContentType returns the media type written by the encoder
+func (e *encoder) ContentType() string {
+	return e.contentType
+}
+
+// Encode writes the specified error to the response
+func (e *encoder) Encode(w http.ResponseWriter, r *http.Request, err *Error) error {
+	return e.fn(w, r, err)
+}
+
+// EncodeData writes the specified status and data to the response
+func (e *dataEncoder) EncodeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	return e.dataFn(w, r, status, data)
+}
+
+func encodeJSend(w http.ResponseWriter, r *http.Request, err *Error) error {
+	s := err.StatusCode()
+
+	w.Header().Set("Content-Type", "application/json")
+	jw := jsend.Wrap(w).Status(s)
+
+	if err.IsFail() && s < http.StatusInternalServerError {
+		jw = jw.Data(err.Fields())
+	} else {
+		jw = jw.Message(err.Message())
+
+		if f := err.Fields(); len(f) > 0 {
+			jw = jw.Data(f)
+		}
+	}
+
+	if ac := err.AppCode(); ac != "" {
+		jw = jw.Field("errorCode", ac)
+	}
+
+	if u := err.DocURL(); u != "" {
+		jw = jw.Field("docUrl", u)
+	}
+
+	_, werr := jw.Send()
+	return werr
+}
+
+func encodeJSendData(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	_, werr := jsend.Wrap(w).
+		Status(status).
+		Data(data).
+		Send()
+
+	return werr
+}
+
+func encodeProblem(w http.ResponseWriter, r *http.Request, err *Error) error {
+	s := err.StatusCode()
+
+	p := make(map[string]interface{}, len(err.Fields())+5)
+	for k, v := range err.Fields() {
+		p[k] = v
+	}
+
+	p["type"] = "about:blank"
+	p["title"] = http.StatusText(s)
+	p["status"] = s
+	p["detail"] = err.Message()
+
+	if u := err.DocURL(); u != "" {
+		p["type"] = u
+	}
+
+	if ac := err.AppCode(); ac != "" {
+		p["errorCode"] = ac
+	}
+
+	if rid, ok := GetRequestID(r); ok {
+		p["instance"] = rid
+	}
+
+	b, merr := json.Marshal(p)
+	if merr != nil {
+		return merr
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(s)
+
+	_, werr := w.Write(b)
+	return werr
+}
+
+func encodeText(w http.ResponseWriter, r *http.Request, err *Error) error {
+	f := err.Fields()
+	v := err.Violations()
+
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		if k == ViolationsField && len(v) > 0 {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.StatusCode())
+
+	if _, werr := fmt.Fprintln(w, err.Message()); werr != nil {
+		return werr
+	}
+
+	if ac := err.AppCode(); ac != "" {
+		if _, werr := fmt.Fprintf(w, "errorCode: %s\n", ac); werr != nil {
+			return werr
+		}
+	}
+
+	if u := err.DocURL(); u != "" {
+		if _, werr := fmt.Fprintf(w, "docUrl: %s\n", u); werr != nil {
+			return werr
+		}
+	}
+
+	for _, k := range keys {
+		if _, werr := fmt.Fprintf(w, "%s: %v\n", k, f[k]); werr != nil {
+			return werr
+		}
+	}
+
+	for _, vv := range v {
+		if _, werr := fmt.Fprintf(w, "%s: %s\n", vv.Path, vv.Message); werr != nil {
+			return werr
+		}
+	}
+
+	return nil
+}
+
+func registeredEncoders() []ErrorEncoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	return append([]ErrorEncoder(nil), encoders...)
+}
+
+// dataEncoders returns the encoders that implement DataEncoder
+func dataEncoders(encs []ErrorEncoder) []ErrorEncoder {
+	var des []ErrorEncoder
+	for _, e := range encs {
+		if _, ok := e.(DataEncoder); ok {
+			des = append(des, e)
+		}
+	}
+
+	return des
+}
+
+func negotiateEncoder(r *http.Request, def ErrorEncoder, encs []ErrorEncoder) ErrorEncoder {
+	ranges := parseAccept(strings.Join(r.Header.Values("Accept"), ","))
+	if len(ranges) < 1 {
+		return def
+	}
+
+	best, bestQ := def, acceptQuality(ranges, def.ContentType())
+	for _, e := range encs {
+		if q := acceptQuality(ranges, e.ContentType()); q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+
+	if bestQ <= 0 {
+		return def
+	}
+
+	return best
+}
+
+func parseAccept(h string) []mediaRange {
+	var ranges []mediaRange
+	for _, v := range strings.Split(h, ",") {
+		parts := strings.Split(v, ";")
+
+		mt := mediaType(parts[0])
+		i := strings.Index(mt, "/")
+		if i < 1 || i == len(mt)-1 {
+			continue
+		}
+
+		mr := mediaRange{typ: mt[:i], subtype: mt[i+1:], q: 1}
+		for _, p := range parts[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					mr.q = q
+				}
+			}
+		}
+
+		ranges = append(ranges, mr)
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range that matches the content type
+func acceptQuality(ranges []mediaRange, contentType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType(contentType), "/")
+
+	q, spec := 0.0, -1
+	for _, mr := range ranges {
+		var s int
+		switch {
+		case mr.typ == typ && mr.subtype == subtype:
+			s = 2
+		case mr.typ == typ && mr.subtype == "*":
+			s = 1
+		case mr.typ == "*" && mr.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > spec {
+			q, spec = mr.q, s
+		}
+	}
+
+	return q
+}
+
+func mediaType(v string) string {
+	if i := strings.Index(v, ";"); i >= 0 {
+		v = v[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(v))
+}
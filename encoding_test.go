@@ -0,0 +1,406 @@
+package strudel_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestJSend(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *strudel.Error
+		code int
+		body map[string]interface{}
+	}{
+		{
+			name: "should write the message",
+			err:  strudel.NewError("error"),
+			code: http.StatusInternalServerError,
+			body: map[string]interface{}{
+				"status":  "error",
+				"message": "error",
+			},
+		},
+		{
+			name: "should write the fields as data",
+			err:  strudel.NewError("error").WithCode(http.StatusServiceUnavailable).WithField("key", "value"),
+			code: http.StatusServiceUnavailable,
+			body: map[string]interface{}{
+				"status":  "error",
+				"message": "error",
+				"data":    map[string]interface{}{"key": "value"},
+			},
+		},
+		{
+			name: "should write the catalog code and documentation url",
+			err: (&strudel.Definition{
+				Code:    "ORDER_CONFLICT",
+				Status:  http.StatusConflict,
+				Message: "order conflict",
+				DocURL:  "https://example.com/errors/order-conflict",
+			}).New().AsFail(),
+			code: http.StatusConflict,
+			body: map[string]interface{}{
+				"status":    "fail",
+				"data":      map[string]interface{}{},
+				"errorCode": "ORDER_CONFLICT",
+				"docUrl":    "https://example.com/errors/order-conflict",
+			},
+		},
+		{
+			name: "should write fail errors as data",
+			err:  strudel.NewError("error").WithCode(http.StatusConflict).WithField("key", "value").AsFail(),
+			code: http.StatusConflict,
+			body: map[string]interface{}{
+				"status": "fail",
+				"data":   map[string]interface{}{"key": "value"},
+			},
+		},
+		{
+			name: "should write server fail errors as errors",
+			err:  strudel.NewError("error").WithCode(http.StatusBadGateway).AsFail(),
+			code: http.StatusBadGateway,
+			body: map[string]interface{}{
+				"status":  "error",
+				"message": "error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.JSend.Encode(rec, req, tt.err); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			assertResponse(t, rec, tt.code, "application/json", tt.body)
+		})
+	}
+}
+
+func TestProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		rid  string
+		err  *strudel.Error
+		code int
+		body map[string]interface{}
+	}{
+		{
+			name: "should use status 500 if code is not HTTP status code",
+			err:  strudel.NewError("error").WithCode(1),
+			code: http.StatusInternalServerError,
+			body: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  http.StatusText(http.StatusInternalServerError),
+				"status": float64(http.StatusInternalServerError),
+				"detail": "error",
+			},
+		},
+		{
+			name: "should set the instance to the request id",
+			rid:  "requestId",
+			err:  strudel.NewError("error").WithCode(http.StatusNotFound),
+			code: http.StatusNotFound,
+			body: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    http.StatusText(http.StatusNotFound),
+				"status":   float64(http.StatusNotFound),
+				"detail":   "error",
+				"instance": "requestId",
+			},
+		},
+		{
+			name: "should write the catalog code and documentation url",
+			err: (&strudel.Definition{
+				Code:    "ORDER_NOT_FOUND",
+				Status:  http.StatusNotFound,
+				Message: "order not found",
+				DocURL:  "https://example.com/errors/order-not-found",
+			}).New(),
+			code: http.StatusNotFound,
+			body: map[string]interface{}{
+				"type":      "https://example.com/errors/order-not-found",
+				"title":     http.StatusText(http.StatusNotFound),
+				"status":    float64(http.StatusNotFound),
+				"detail":    "order not found",
+				"errorCode": "ORDER_NOT_FOUND",
+			},
+		},
+		{
+			name: "should write fields as extension members",
+			err: strudel.NewError("error").
+				WithCode(http.StatusConflict).
+				WithField("key", "value").
+				WithField("status", "value").
+				WithLogField("logKey", "value"),
+			code: http.StatusConflict,
+			body: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  http.StatusText(http.StatusConflict),
+				"status": float64(http.StatusConflict),
+				"detail": "error",
+				"key":    "value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreRequestID := setRequestID(tt.rid)
+			defer restoreRequestID()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.Problem.Encode(rec, req, tt.err); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			assertResponse(t, rec, tt.code, "application/problem+json", tt.body)
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *strudel.Error
+		code int
+		body string
+	}{
+		{
+			name: "should write the message",
+			err:  strudel.NewError("error").WithCode(http.StatusNotFound),
+			code: http.StatusNotFound,
+			body: "error\n",
+		},
+		{
+			name: "should write the fields in key order",
+			err:  strudel.NewError("error").WithField("keyB", "valueB").WithField("keyA", 1).WithLogField("logKey", "value"),
+			code: http.StatusInternalServerError,
+			body: "error\nkeyA: 1\nkeyB: valueB\n",
+		},
+		{
+			name: "should write the catalog code and documentation url",
+			err: (&strudel.Definition{
+				Code:    "ORDER_NOT_FOUND",
+				Status:  http.StatusNotFound,
+				Message: "order not found",
+				DocURL:  "https://example.com/errors/order-not-found",
+			}).New().WithField("id", "abc"),
+			code: http.StatusNotFound,
+			body: "order not found\nerrorCode: ORDER_NOT_FOUND\ndocUrl: https://example.com/errors/order-not-found\nid: abc\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.Text.Encode(rec, req, tt.err); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if rec.Code != tt.code {
+				t.Errorf("got %d, expected %d", rec.Code, tt.code)
+			}
+
+			if act, exp := rec.Header().Get("Content-Type"), "text/plain; charset=utf-8"; act != exp {
+				t.Errorf("got %s, expected %s", act, exp)
+			}
+
+			if act := rec.Body.String(); act != tt.body {
+				t.Errorf("got %q, expected %q", act, tt.body)
+			}
+		})
+	}
+}
+
+func TestNewErrorEncoder(t *testing.T) {
+	t.Run("should use the content type and function", func(t *testing.T) {
+		const contentType = "application/vnd.custom+json"
+
+		var called bool
+		e := strudel.NewErrorEncoder(contentType, func(w http.ResponseWriter, r *http.Request, err *strudel.Error) error {
+			called = true
+			return nil
+		})
+
+		if act := e.ContentType(); act != contentType {
+			t.Errorf("got %s, expected %s", act, contentType)
+		}
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		if err := e.Encode(rec, req, strudel.NewError("error")); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if !called {
+			t.Error("got false, expected true")
+		}
+	})
+}
+
+func TestNewDataEncoder(t *testing.T) {
+	t.Run("should use the content type and functions", func(t *testing.T) {
+		const contentType = "application/vnd.custom+json"
+
+		var errCalled, dataCalled bool
+		e := strudel.NewDataEncoder(contentType, func(http.ResponseWriter, *http.Request, *strudel.Error) error {
+			errCalled = true
+			return nil
+		}, func(http.ResponseWriter, *http.Request, int, interface{}) error {
+			dataCalled = true
+			return nil
+		})
+
+		if act := e.ContentType(); act != contentType {
+			t.Errorf("got %s, expected %s", act, contentType)
+		}
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		if err := e.Encode(rec, req, strudel.NewError("error")); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if err := e.EncodeData(rec, req, http.StatusOK, nil); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if !errCalled || !dataCalled {
+			t.Errorf("got %t, %t, expected true, true", errCalled, dataCalled)
+		}
+	})
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	t.Run("should replace encoders with the same content type", func(t *testing.T) {
+		e := strudel.NewErrorEncoder("text/plain; charset=utf-8", func(http.ResponseWriter, *http.Request, *strudel.Error) error {
+			return nil
+		})
+
+		strudel.RegisterEncoder(e)
+		defer strudel.RegisterEncoder(strudel.Text)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "text/plain")
+
+		if act := strudel.NegotiateEncoder(req, strudel.JSend); act != e {
+			t.Errorf("got %v, expected %v", act, e)
+		}
+	})
+}
+
+func TestNegotiateEncoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept []string
+		def    strudel.ErrorEncoder
+		exp    strudel.ErrorEncoder
+	}{
+		{
+			name: "should return the default encoder if accept is not set",
+			def:  strudel.Problem,
+			exp:  strudel.Problem,
+		},
+		{
+			name:   "should return the default encoder if accept is invalid",
+			accept: []string{"invalid"},
+			def:    strudel.JSend,
+			exp:    strudel.JSend,
+		},
+		{
+			name:   "should return the default encoder if no encoder is acceptable",
+			accept: []string{"application/xml"},
+			def:    strudel.JSend,
+			exp:    strudel.JSend,
+		},
+		{
+			name:   "should return the default encoder for wildcard ranges",
+			accept: []string{"*/*"},
+			def:    strudel.Problem,
+			exp:    strudel.Problem,
+		},
+		{
+			name:   "should return the matching encoder",
+			accept: []string{"text/plain"},
+			def:    strudel.JSend,
+			exp:    strudel.Text,
+		},
+		{
+			name:   "should match type wildcards",
+			accept: []string{"text/*"},
+			def:    strudel.JSend,
+			exp:    strudel.Text,
+		},
+		{
+			name:   "should use quality values",
+			accept: []string{"application/json;q=0.5, application/problem+json;q=0.9"},
+			def:    strudel.JSend,
+			exp:    strudel.Problem,
+		},
+		{
+			name:   "should use the most specific range quality",
+			accept: []string{"text/plain;q=0, */*;q=0.5"},
+			def:    strudel.Text,
+			exp:    strudel.JSend,
+		},
+		{
+			name:   "should combine multiple headers",
+			accept: []string{"application/xml", "application/problem+json"},
+			def:    strudel.JSend,
+			exp:    strudel.Problem,
+		},
+		{
+			name:   "should ignore case",
+			accept: []string{"Application/Problem+JSON"},
+			def:    strudel.JSend,
+			exp:    strudel.Problem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, v := range tt.accept {
+				req.Header.Add("Accept", v)
+			}
+
+			act := strudel.NegotiateEncoder(req, tt.def)
+
+			if act != tt.exp {
+				t.Errorf("got %s, expected %s", act.ContentType(), tt.exp.ContentType())
+			}
+		})
+	}
+}
+
+func assertResponse(t *testing.T, rec *httptest.ResponseRecorder, code int, contentType string, body map[string]interface{}) {
+	t.Helper()
+
+	if rec.Code != code {
+		t.Errorf("got %d, expected %d", rec.Code, code)
+	}
+
+	if act := rec.Header().Get("Content-Type"); act != contentType {
+		t.Errorf("got %s, expected %s", act, contentType)
+	}
+
+	act := map[string]interface{}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &act); err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+
+	if !reflect.DeepEqual(act, body) {
+		t.Errorf("got %v, expected %v", act, body)
+	}
+}
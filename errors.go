@@ -1,6 +1,10 @@
 package strudel
 
-import "strings"
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
 
 type (
 	// Fields represents a set of error fields
@@ -8,17 +12,67 @@ type (
 
 	// Error represents an error
 	Error struct {
-		msg       string
-		code      int
-		fields    Fields
-		logFields Fields
+		msg        string
+		code       int
+		cause      error
+		fields     Fields
+		logFields  Fields
+		stack      []Frame
+		violations []Violation
+		fail       bool
+		recovered  bool
+		appCode    string
+		docURL     string
+		msgKey     string
+		msgParams  Fields
 	}
 )
 
+// CaptureStack enables stack capture when errors are created
+// It is disabled by default as capturing the stack has a cost for every error
+var CaptureStack = false
+
 // NewError returns a new error
 func NewError(msg string) *Error {
+	return newError(msg, nil)
+}
+
+// Wrap returns a new error with the specified cause
+func Wrap(err error, msg string) *Error {
+	return newError(msg, err)
+}
+
+// AsError returns the first error in the chain that is an *Error
+// Catalog definitions in the chain are returned as new errors for the definition
+func AsError(err error) (*Error, bool) {
+	var se *Error
+	if errors.As(err, &se) {
+		return se, true
+	}
+
+	var d *Definition
+	if errors.As(err, &d) {
+		return d.apply(newBareError(d.Message, nil)), true
+	}
+
+	return nil, false
+}
+
+func newError(msg string, cause error) *Error {
+	e := newBareError(msg, cause)
+	if CaptureStack {
+		e.stack = callers(3)
+	}
+
+	return e
+}
+
+// newBareError returns a new error without capturing the stack
+// It is used for errors created by the middleware, where the stack would not identify the error source
+func newBareError(msg string, cause error) *Error {
 	return &Error{
 		msg:       msg,
+		cause:     cause,
 		fields:    Fields{},
 		logFields: Fields{},
 	}
@@ -31,6 +85,14 @@ func (e *Error) WithCode(code int) *Error {
 	return e
 }
 
+// AsFail flags the error as a client failure
+// Client failures with a 4xx status are written as jsend fail responses with the fields as data
+func (e *Error) AsFail() *Error {
+	e.fail = true
+
+	return e
+}
+
 // WithField adds the specified error field
 func (e *Error) WithField(key string, value interface{}) *Error {
 	if strings.TrimSpace(key) != "" {
@@ -67,16 +129,83 @@ func (e *Error) WithLogFields(f Fields) *Error {
 	return e
 }
 
-// Error returns the error message
+// Error returns the error message, including the cause if one has been set
 func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.msg + ": " + e.cause.Error()
+	}
+
 	return e.msg
 }
 
+// Message returns the error message, excluding the cause
+func (e *Error) Message() string {
+	return e.msg
+}
+
+// Unwrap returns the error cause
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is returns true if the target is an error or definition with the same application error code
+func (e *Error) Is(target error) bool {
+	if e.appCode == "" {
+		return false
+	}
+
+	switch t := target.(type) {
+	case *Error:
+		return t.appCode == e.appCode
+	case *Definition:
+		return t.Code == e.appCode
+	default:
+		return false
+	}
+}
+
 // Code returns the error code
 func (e *Error) Code() int {
 	return e.code
 }
 
+// AppCode returns the application error code set by the catalog definition
+func (e *Error) AppCode() string {
+	return e.appCode
+}
+
+// DocURL returns the documentation url set by the catalog definition
+func (e *Error) DocURL() string {
+	return e.docURL
+}
+
+// IsFail returns true if the error has been flagged as a client failure
+func (e *Error) IsFail() bool {
+	return e.fail
+}
+
+// Recovered returns true if the error was created by Recovery for a recovered panic
+// The stack of a recovered error is the stack of the panicking function
+func (e *Error) Recovered() bool {
+	return e.recovered
+}
+
+// Stack returns the call stack captured when the error was created
+// The stack is only captured if CaptureStack is enabled
+func (e *Error) Stack() []Frame {
+	return e.stack
+}
+
+// StatusCode returns the HTTP status code for the error
+// If the error code is not a valid HTTP error status then 500 is returned
+func (e *Error) StatusCode() int {
+	if e.code >= 400 && e.code < 600 {
+		return e.code
+	}
+
+	return http.StatusInternalServerError
+}
+
 // Fields returns all error fields that are not log-only
 func (e *Error) Fields() Fields {
 	return e.fields
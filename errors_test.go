@@ -1,8 +1,11 @@
 package strudel_test
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stevecallear/strudel"
@@ -20,6 +23,87 @@ func TestNewError(t *testing.T) {
 	})
 }
 
+func TestWrap(t *testing.T) {
+	t.Run("should set the message and cause", func(t *testing.T) {
+		cause := errors.New("cause")
+
+		err := strudel.Wrap(cause, "error")
+
+		if act, exp := err.Message(), "error"; act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+
+		if act := err.Unwrap(); act != cause {
+			t.Errorf("got %v, expected %v", act, cause)
+		}
+	})
+}
+
+func TestError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *strudel.Error
+		exp  string
+	}{
+		{
+			name: "should return the message",
+			err:  strudel.NewError("error"),
+			exp:  "error",
+		},
+		{
+			name: "should include the cause",
+			err:  strudel.Wrap(errors.New("cause"), "error"),
+			exp:  "error: cause",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.err.Error()
+
+			if act != tt.exp {
+				t.Errorf("got %s, expected %s", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestError_Unwrap(t *testing.T) {
+	cause := errors.New("cause")
+
+	tests := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{
+			name: "should not match if there is no cause",
+			err:  strudel.NewError("error"),
+			exp:  false,
+		},
+		{
+			name: "should match the cause",
+			err:  strudel.Wrap(cause, "error"),
+			exp:  true,
+		},
+		{
+			name: "should match a wrapped cause",
+			err:  fmt.Errorf("context: %w", strudel.Wrap(fmt.Errorf("inner: %w", cause), "error")),
+			exp:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := errors.Is(tt.err, cause)
+
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
 func TestError_WithCode(t *testing.T) {
 	tests := []struct {
 		name string
@@ -50,6 +134,118 @@ func TestError_WithCode(t *testing.T) {
 	}
 }
 
+func TestError_AsFail(t *testing.T) {
+	t.Run("should not be flagged by default", func(t *testing.T) {
+		if strudel.NewError("error").IsFail() {
+			t.Error("got true, expected false")
+		}
+	})
+
+	t.Run("should flag the error", func(t *testing.T) {
+		if !strudel.NewError("error").AsFail().IsFail() {
+			t.Error("got false, expected true")
+		}
+	})
+}
+
+func TestError_Stack(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture bool
+		fn      func() *strudel.Error
+		exp     string
+	}{
+		{
+			name: "should not capture the stack by default",
+			fn: func() *strudel.Error {
+				return strudel.NewError("error")
+			},
+		},
+		{
+			name:    "should capture the stack for new errors",
+			capture: true,
+			fn: func() *strudel.Error {
+				return strudel.NewError("error")
+			},
+			exp: "github.com/stevecallear/strudel_test.TestError_Stack.func2",
+		},
+		{
+			name:    "should capture the stack for wrapped errors",
+			capture: true,
+			fn: func() *strudel.Error {
+				return strudel.Wrap(errors.New("cause"), "error")
+			},
+			exp: "github.com/stevecallear/strudel_test.TestError_Stack.func3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreCaptureStack := setCaptureStack(tt.capture)
+			defer restoreCaptureStack()
+
+			st := tt.fn().Stack()
+
+			if tt.exp == "" {
+				if len(st) > 0 {
+					t.Errorf("got %v, expected empty stack", st)
+				}
+				return
+			}
+
+			if len(st) < 1 {
+				t.Fatal("got empty stack, expected frames")
+			}
+
+			if st[0].Function != tt.exp {
+				t.Errorf("got %s, expected %s", st[0].Function, tt.exp)
+			}
+
+			if !strings.HasSuffix(st[0].File, "errors_test.go") || st[0].Line < 1 {
+				t.Errorf("got %s:%d, expected errors_test.go", st[0].File, st[0].Line)
+			}
+		})
+	}
+}
+
+func TestError_StatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		exp  int
+	}{
+		{
+			name: "should return 500 if the code is not set",
+			exp:  http.StatusInternalServerError,
+		},
+		{
+			name: "should return 500 if the code is not an HTTP error status",
+			code: http.StatusOK,
+			exp:  http.StatusInternalServerError,
+		},
+		{
+			name: "should return 4xx codes",
+			code: http.StatusNotFound,
+			exp:  http.StatusNotFound,
+		},
+		{
+			name: "should return 5xx codes",
+			code: http.StatusServiceUnavailable,
+			exp:  http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := strudel.NewError("error").WithCode(tt.code).StatusCode()
+
+			if act != tt.exp {
+				t.Errorf("got %d, expected %d", act, tt.exp)
+			}
+		})
+	}
+}
+
 func TestError_WithField(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -1,11 +1,30 @@
 module github.com/stevecallear/strudel
 
-go 1.16
+go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/felixge/httpsnoop v1.0.2
 	github.com/gamegos/jsend v0.0.0-20151011171802-f47e169f3d76
-	github.com/google/uuid v1.3.0
+	github.com/go-chi/chi/v5 v5.1.0
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/mux v1.8.1
+	github.com/rs/zerolog v1.33.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stevecallear/janice v1.2.1
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	go.uber.org/zap v1.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 )
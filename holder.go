@@ -0,0 +1,60 @@
+package strudel
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// requestHolder holds request values that are set by inner middleware and handlers
+// It allows outer middleware to read the route, request id and handled error after the request has been served
+type requestHolder struct {
+	route     atomic.Value
+	requestID atomic.Value
+	err       atomic.Value
+}
+
+var holderKey = contextKey("holder")
+
+// HandledError returns the error written by ErrorHandling or Recovery for the specified request
+// It allows outer middleware, such as tracing, to record errors that are not returned to it
+// False is returned if no error has been handled or the request is not tracked
+func HandledError(r *http.Request) (*Error, bool) {
+	h, ok := getHolder(r)
+	if !ok {
+		return nil, false
+	}
+
+	err, _ := h.err.Load().(*Error)
+	return err, err != nil
+}
+
+// setHandledError records the handled error for outer middleware if the request is tracked
+func setHandledError(r *http.Request, err *Error) {
+	if h, ok := getHolder(r); ok {
+		h.err.Store(err)
+	}
+}
+
+func getHolder(r *http.Request) (*requestHolder, bool) {
+	h, ok := r.Context().Value(holderKey).(*requestHolder)
+	return h, ok
+}
+
+// withHolder returns the request with a holder if it does not already have one
+func withHolder(r *http.Request) *http.Request {
+	if _, ok := getHolder(r); ok {
+		return r
+	}
+
+	return r.WithContext(holderContext(r.Context()))
+}
+
+// holderContext returns the context with a holder if it does not already have one
+func holderContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(holderKey).(*requestHolder); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, holderKey, new(requestHolder))
+}
@@ -0,0 +1,95 @@
+package strudel_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestHandledError(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func() error
+		track     bool
+		code      int
+		recovered bool
+		ok        bool
+	}{
+		{
+			name:  "should return false if no error has been handled",
+			fn:    func() error { return nil },
+			track: true,
+		},
+		{
+			name: "should return false if the request is not tracked",
+			fn: func() error {
+				return strudel.NewError("error").WithCode(http.StatusNotFound)
+			},
+		},
+		{
+			name: "should return errors handled by ErrorHandling",
+			fn: func() error {
+				return strudel.NewError("error").WithCode(http.StatusNotFound)
+			},
+			track: true,
+			code:  http.StatusNotFound,
+			ok:    true,
+		},
+		{
+			name:  "should return other errors as internal server errors",
+			fn:    func() error { return errors.New("error") },
+			track: true,
+			code:  http.StatusInternalServerError,
+			ok:    true,
+		},
+		{
+			name:      "should return recovered panics",
+			fn:        func() error { panic("error") },
+			track:     true,
+			code:      http.StatusInternalServerError,
+			recovered: true,
+			ok:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreLogger := setLogger(io.Discard)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			if tt.track {
+				req = strudel.SetTraceContext(req, strudel.TraceContext{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331"})
+			}
+
+			strudel.Recovery(strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+				return tt.fn()
+			}))(rec, req)
+
+			act, ok := strudel.HandledError(req)
+			if ok != tt.ok {
+				t.Fatalf("got %v, expected %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if c := act.StatusCode(); c != tt.code {
+				t.Errorf("got %d, expected %d", c, tt.code)
+			}
+
+			if act.Recovered() != tt.recovered {
+				t.Errorf("got %v, expected %v", act.Recovered(), tt.recovered)
+			}
+
+			if tt.recovered && len(act.Stack()) < 1 {
+				t.Error("got no stack, expected panic stack")
+			}
+		})
+	}
+}
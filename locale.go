@@ -0,0 +1,273 @@
+package strudel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// MessageLocalizer represents a client message localizer
+	MessageLocalizer interface {
+		// Localize returns the localized message and locale for the specified key and params
+		// False is returned if no message is available for the request
+		Localize(r *http.Request, key string, params Fields) (msg string, locale string, ok bool)
+	}
+
+	// MessageBundle represents a set of message templates by locale
+	// Templates can contain {name} placeholders that are replaced with the message params
+	MessageBundle struct {
+		mu       sync.RWMutex
+		fallback string
+		tags     map[string]string
+		messages map[string]map[string]string
+		formats  map[string]UnmarshalFunc
+	}
+
+	// UnmarshalFunc represents a message file unmarshal function, such as json.Unmarshal
+	UnmarshalFunc func(data []byte, v interface{}) error
+
+	languageRange struct {
+		tag string
+		q   float64
+	}
+)
+
+// Localizer is the localizer used to write client error messages
+// Error messages are not localized if it is nil
+var Localizer MessageLocalizer
+
+// NewMessageBundle returns a new message bundle with the specified fallback locale
+// The fallback locale is used if the request does not accept any bundle locale
+func NewMessageBundle(fallback string) *MessageBundle {
+	return &MessageBundle{
+		fallback: normalizeLocale(fallback),
+		tags:     map[string]string{},
+		messages: map[string]map[string]string{},
+		formats:  map[string]UnmarshalFunc{".json": json.Unmarshal},
+	}
+}
+
+// RegisterFormat configures the function used to unmarshal message files with the specified extension
+// JSON files are supported by default. Other formats can be added, for example RegisterFormat(".toml", toml.Unmarshal)
+func (b *MessageBundle) RegisterFormat(ext string, fn UnmarshalFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.formats[strings.ToLower(ext)] = fn
+}
+
+// AddMessages adds the specified message templates for the locale
+func (b *MessageBundle) AddMessages(locale string, msgs map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l := normalizeLocale(locale)
+	if b.messages[l] == nil {
+		b.tags[l] = strings.TrimSpace(locale)
+		b.messages[l] = map[string]string{}
+	}
+
+	for k, v := range msgs {
+		b.messages[l][k] = v
+	}
+}
+
+// LoadFile adds the message templates from the specified file
+// The locale is taken from the file name, for example fr.json or de-DE.json
+func (b *MessageBundle) LoadFile(name string) error {
+	return b.LoadFS(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+}
+
+// LoadFS adds the message templates from the files matching the pattern
+// An error is returned if a file does not have a registered format
+func (b *MessageBundle) LoadFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	if len(names) < 1 {
+		return fmt.Errorf("strudel: no message files match %s", pattern)
+	}
+
+	for _, n := range names {
+		bs, err := fs.ReadFile(fsys, n)
+		if err != nil {
+			return err
+		}
+
+		ext := path.Ext(n)
+
+		b.mu.RLock()
+		fn, ok := b.formats[strings.ToLower(ext)]
+		b.mu.RUnlock()
+
+		if !ok {
+			return fmt.Errorf("strudel: unsupported message file %s", n)
+		}
+
+		msgs := map[string]string{}
+		if err = fn(bs, &msgs); err != nil {
+			return fmt.Errorf("strudel: invalid message file %s: %w", n, err)
+		}
+
+		b.AddMessages(strings.TrimSuffix(path.Base(n), ext), msgs)
+	}
+
+	return nil
+}
+
+// Locales returns the bundle locales
+func (b *MessageBundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ls := make([]string, 0, len(b.tags))
+	for _, t := range b.tags {
+		ls = append(ls, t)
+	}
+	sort.Strings(ls)
+
+	return ls
+}
+
+// Localize returns the message for the best matching Accept-Language locale
+// Locales are matched exactly, then by base language, then using the fallback locale
+func (b *MessageBundle) Localize(r *http.Request, key string, params Fields) (string, string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, l := range b.match(parseAcceptLanguage(strings.Join(r.Header.Values("Accept-Language"), ","))) {
+		if t, ok := b.messages[l][key]; ok {
+			return formatMessage(t, params), b.tags[l], true
+		}
+	}
+
+	return "", "", false
+}
+
+// match returns the candidate bundle locales for the language ranges in order of preference
+func (b *MessageBundle) match(ranges []languageRange) []string {
+	var ls []string
+	for _, lr := range ranges {
+		if lr.tag == "*" {
+			break
+		}
+
+		if _, ok := b.messages[lr.tag]; ok {
+			ls = append(ls, lr.tag)
+		}
+
+		base, _, _ := strings.Cut(lr.tag, "-")
+		if _, ok := b.messages[base]; ok && base != lr.tag {
+			ls = append(ls, base)
+		}
+
+		if base == lr.tag {
+			for _, l := range b.sortedLocales() {
+				if strings.HasPrefix(l, base+"-") {
+					ls = append(ls, l)
+				}
+			}
+		}
+	}
+
+	return append(ls, b.fallback)
+}
+
+func (b *MessageBundle) sortedLocales() []string {
+	ls := make([]string, 0, len(b.messages))
+	for l := range b.messages {
+		ls = append(ls, l)
+	}
+	sort.Strings(ls)
+
+	return ls
+}
+
+// WithMessageKey sets the key and params used to localize the client message
+func (e *Error) WithMessageKey(key string, params Fields) *Error {
+	e.msgKey = key
+	e.msgParams = params
+
+	return e
+}
+
+// MessageKey returns the key used to localize the client message
+func (e *Error) MessageKey() string {
+	return e.msgKey
+}
+
+// MessageParams returns the params used to localize the client message
+func (e *Error) MessageParams() Fields {
+	return e.msgParams
+}
+
+// localize returns a copy of the error with the localized client message
+// The original error is returned if the message cannot be localized
+func localize(l MessageLocalizer, w http.ResponseWriter, r *http.Request, err *Error) *Error {
+	if l == nil || err.msgKey == "" {
+		return err
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+
+	msg, locale, ok := l.Localize(r, err.msgKey, err.msgParams)
+	if !ok {
+		return err
+	}
+
+	w.Header().Set("Content-Language", locale)
+
+	c := *err
+	c.msg = msg
+
+	return &c
+}
+
+// parseAcceptLanguage returns the acceptable language ranges ordered by quality
+func parseAcceptLanguage(h string) []languageRange {
+	var ranges []languageRange
+	for _, v := range strings.Split(h, ",") {
+		parts := strings.Split(v, ";")
+
+		tag := normalizeLocale(parts[0])
+		if tag == "" {
+			continue
+		}
+
+		lr := languageRange{tag: tag, q: 1}
+		for _, p := range parts[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					lr.q = q
+				}
+			}
+		}
+
+		if lr.q > 0 {
+			ranges = append(ranges, lr)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	return ranges
+}
+
+// normalizeLocale returns the lower case hyphenated locale
+func normalizeLocale(l string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(l), "_", "-"))
+}
@@ -0,0 +1,288 @@
+package strudel_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestMessageBundle_LoadFile(t *testing.T) {
+	t.Run("should load the messages", func(t *testing.T) {
+		b := strudel.NewMessageBundle("en")
+		b.RegisterFormat(".toml", toml.Unmarshal)
+
+		for _, f := range []string{"testdata/locales/fr.json", "testdata/locales/de-DE.toml"} {
+			if err := b.LoadFile(f); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+		}
+
+		if act, exp := b.Locales(), []string{"de-DE", "fr"}; !reflect.DeepEqual(act, exp) {
+			t.Errorf("got %v, expected %v", act, exp)
+		}
+	})
+}
+
+func TestMessageBundle_LoadFS(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		formats map[string]strudel.UnmarshalFunc
+		pattern string
+		err     bool
+	}{
+		{
+			name: "should load matching files",
+			fsys: fstest.MapFS{
+				"locales/fr.json": {Data: []byte(`{"key": "value"}`)},
+			},
+			pattern: "locales/*",
+		},
+		{
+			name: "should use registered formats",
+			fsys: fstest.MapFS{
+				"locales/fr.json": {Data: []byte(`{"key": "value"}`)},
+				"locales/de.TOML": {Data: []byte(`key = "value"`)},
+			},
+			formats: map[string]strudel.UnmarshalFunc{".toml": toml.Unmarshal},
+			pattern: "locales/*",
+		},
+		{
+			name: "should return an error for unregistered formats",
+			fsys: fstest.MapFS{
+				"locales/de.toml": {Data: []byte(`key = "value"`)},
+			},
+			pattern: "locales/*",
+			err:     true,
+		},
+		{
+			name:    "should return an error if no files match",
+			fsys:    fstest.MapFS{},
+			pattern: "locales/*",
+			err:     true,
+		},
+		{
+			name: "should return an error for unsupported files",
+			fsys: fstest.MapFS{
+				"locales/fr.yaml": {Data: []byte(`key: value`)},
+			},
+			pattern: "locales/*",
+			err:     true,
+		},
+		{
+			name: "should return an error for invalid files",
+			fsys: fstest.MapFS{
+				"locales/fr.json": {Data: []byte(`{"key": 1}`)},
+			},
+			pattern: "locales/*",
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := strudel.NewMessageBundle("en")
+			for ext, fn := range tt.formats {
+				b.RegisterFormat(ext, fn)
+			}
+
+			err := b.LoadFS(tt.fsys, tt.pattern)
+			if act, exp := err != nil, tt.err; act != exp {
+				t.Errorf("got %v, expected error %t", err, exp)
+			}
+		})
+	}
+}
+
+func TestMessageBundle_Localize(t *testing.T) {
+	b := strudel.NewMessageBundle("en")
+	b.AddMessages("en", map[string]string{"greeting": "hello {name}"})
+	b.AddMessages("fr", map[string]string{"greeting": "bonjour {name}"})
+	b.AddMessages("de-DE", map[string]string{"greeting": "hallo {name}"})
+	b.AddMessages("pt-BR", map[string]string{"farewell": "tchau"})
+
+	tests := []struct {
+		name   string
+		accept string
+		key    string
+		msg    string
+		locale string
+		ok     bool
+	}{
+		{
+			name:   "should use the fallback locale if accept language is not set",
+			key:    "greeting",
+			msg:    "hello world",
+			locale: "en",
+			ok:     true,
+		},
+		{
+			name:   "should match locales exactly",
+			accept: "de-de",
+			key:    "greeting",
+			msg:    "hallo world",
+			locale: "de-DE",
+			ok:     true,
+		},
+		{
+			name:   "should match base languages",
+			accept: "fr-CA",
+			key:    "greeting",
+			msg:    "bonjour world",
+			locale: "fr",
+			ok:     true,
+		},
+		{
+			name:   "should match regional locales for base languages",
+			accept: "de",
+			key:    "greeting",
+			msg:    "hallo world",
+			locale: "de-DE",
+			ok:     true,
+		},
+		{
+			name:   "should use quality values",
+			accept: "fr;q=0.5, de-DE;q=0.8, es",
+			key:    "greeting",
+			msg:    "hallo world",
+			locale: "de-DE",
+			ok:     true,
+		},
+		{
+			name:   "should ignore unacceptable locales",
+			accept: "de-DE;q=0, fr",
+			key:    "greeting",
+			msg:    "bonjour world",
+			locale: "fr",
+			ok:     true,
+		},
+		{
+			name:   "should fall back if the locale does not have the key",
+			accept: "pt-BR",
+			key:    "greeting",
+			msg:    "hello world",
+			locale: "en",
+			ok:     true,
+		},
+		{
+			name:   "should use the fallback locale for wildcards",
+			accept: "*, fr;q=0.5",
+			key:    "greeting",
+			msg:    "hello world",
+			locale: "en",
+			ok:     true,
+		},
+		{
+			name:   "should return false if the key does not exist",
+			accept: "fr",
+			key:    "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+
+			msg, locale, ok := b.Localize(req, tt.key, strudel.Fields{"name": "world"})
+
+			if act, exp := []interface{}{msg, locale, ok}, []interface{}{tt.msg, tt.locale, tt.ok}; !reflect.DeepEqual(act, exp) {
+				t.Errorf("got %v, expected %v", act, exp)
+			}
+		})
+	}
+}
+
+func TestErrorHandling_Localization(t *testing.T) {
+	b := strudel.NewMessageBundle("en")
+	if err := b.LoadFile("testdata/locales/fr.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &strudel.Definition{Code: "ORDER_NOT_FOUND", Status: http.StatusNotFound, Message: "order {orderId} not found"}
+
+	tests := []struct {
+		name     string
+		accept   string
+		err      *strudel.Error
+		msg      string
+		language string
+	}{
+		{
+			name:     "should write the localized message",
+			accept:   "fr-FR, en;q=0.5",
+			err:      d.NewWithFields(strudel.Fields{"orderId": "abc"}),
+			msg:      "commande abc introuvable",
+			language: "fr",
+		},
+		{
+			name:   "should write the canonical message if the message cannot be localized",
+			accept: "es",
+			err:    d.NewWithFields(strudel.Fields{"orderId": "abc"}),
+			msg:    "order abc not found",
+		},
+		{
+			name:   "should write the canonical message for errors without keys",
+			accept: "fr",
+			err:    strudel.NewError("order abc not found").WithCode(http.StatusNotFound),
+			msg:    "order abc not found",
+		},
+		{
+			name:     "should use the message key and params",
+			accept:   "fr",
+			err:      strudel.NewError("order abc not found").WithCode(http.StatusNotFound).WithMessageKey("ORDER_NOT_FOUND", strudel.Fields{"orderId": "abc"}),
+			msg:      "commande abc introuvable",
+			language: "fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Language", tt.accept)
+
+			err := strudel.New(strudel.WithLocalizer(b)).ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+				return tt.err
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			body := map[string]interface{}{}
+			if err = json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			if act := body["message"]; act != tt.msg {
+				t.Errorf("got %v, expected %s", act, tt.msg)
+			}
+
+			if act := rec.Header().Get("Content-Language"); act != tt.language {
+				t.Errorf("got %s, expected %s", act, tt.language)
+			}
+
+			log := map[string]interface{}{}
+			if err = json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			if act, exp := log["msg"], "order abc not found"; act != exp {
+				t.Errorf("got %v, expected %s", act, exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package strudel
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// Level represents a log level
+	Level int
+
+	// LogSink represents a structured log destination
+	LogSink interface {
+		Log(level Level, msg string, fields Fields)
+	}
+
+	logrusSink struct {
+		logger *logrus.Logger
+	}
+
+	slogSink struct {
+		logger *slog.Logger
+	}
+)
+
+// Log levels
+const (
+	InfoLevel Level = iota
+	WarnLevel
+	ErrorLevel
+)
+
+// String returns the level name
+func (l Level) String() string {
+	switch l {
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// NewLogrusSink returns a new log sink that writes to the specified logrus logger
+func NewLogrusSink(l *logrus.Logger) LogSink {
+	return &logrusSink{logger: l}
+}
+
+// Log writes the specified entry
+func (s *logrusSink) Log(level Level, msg string, fields Fields) {
+	lvl := logrus.InfoLevel
+	switch level {
+	case WarnLevel:
+		lvl = logrus.WarnLevel
+	case ErrorLevel:
+		lvl = logrus.ErrorLevel
+	}
+
+	s.logger.WithFields(logrus.Fields(fields)).Log(lvl, msg)
+}
+
+// NewSlogSink returns a new log sink that writes to the specified slog logger
+func NewSlogSink(l *slog.Logger) LogSink {
+	return &slogSink{logger: l}
+}
+
+// Log writes the specified entry
+func (s *slogSink) Log(level Level, msg string, fields Fields) {
+	lvl := slog.LevelInfo
+	switch level {
+	case WarnLevel:
+		lvl = slog.LevelWarn
+	case ErrorLevel:
+		lvl = slog.LevelError
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.Any(k, fields[k])
+	}
+
+	s.logger.LogAttrs(context.Background(), lvl, msg, attrs...)
+}
@@ -0,0 +1,163 @@
+package strudel_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestLevel_String(t *testing.T) {
+	tests := []struct {
+		level strudel.Level
+		exp   string
+	}{
+		{level: strudel.InfoLevel, exp: "info"},
+		{level: strudel.WarnLevel, exp: "warning"},
+		{level: strudel.ErrorLevel, exp: "error"},
+		{level: strudel.Level(-1), exp: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			if act := tt.level.String(); act != tt.exp {
+				t.Errorf("got %s, expected %s", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestNewLogrusSink(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  strudel.Level
+		msg    string
+		fields strudel.Fields
+		exp    map[string]interface{}
+	}{
+		{
+			name:   "should write info entries",
+			level:  strudel.InfoLevel,
+			fields: strudel.Fields{"type": "request"},
+			exp: map[string]interface{}{
+				"level": "info",
+				"msg":   "",
+				"type":  "request",
+			},
+		},
+		{
+			name:   "should write warn entries",
+			level:  strudel.WarnLevel,
+			msg:    "warning",
+			fields: strudel.Fields{"type": "recovery"},
+			exp: map[string]interface{}{
+				"level": "warning",
+				"msg":   "warning",
+				"type":  "recovery",
+			},
+		},
+		{
+			name:   "should write error entries",
+			level:  strudel.ErrorLevel,
+			msg:    "error",
+			fields: strudel.Fields{"type": "error", "data": strudel.Fields{"key": "value"}},
+			exp: map[string]interface{}{
+				"level": "error",
+				"msg":   "error",
+				"type":  "error",
+				"data":  map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			l := logrus.New()
+			l.Formatter = new(logrus.JSONFormatter)
+			l.Out = buf
+
+			strudel.NewLogrusSink(l).Log(tt.level, tt.msg, tt.fields)
+
+			assertLogEntry(t, buf, tt.exp)
+		})
+	}
+}
+
+func TestNewSlogSink(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  strudel.Level
+		msg    string
+		fields strudel.Fields
+		exp    map[string]interface{}
+	}{
+		{
+			name:   "should write info entries",
+			level:  strudel.InfoLevel,
+			fields: strudel.Fields{"type": "request", "code": 200},
+			exp: map[string]interface{}{
+				"level": "INFO",
+				"msg":   "",
+				"type":  "request",
+				"code":  float64(200),
+			},
+		},
+		{
+			name:   "should write warn entries",
+			level:  strudel.WarnLevel,
+			msg:    "warning",
+			fields: strudel.Fields{"type": "recovery"},
+			exp: map[string]interface{}{
+				"level": "WARN",
+				"msg":   "warning",
+				"type":  "recovery",
+			},
+		},
+		{
+			name:   "should write error entries",
+			level:  strudel.ErrorLevel,
+			msg:    "error",
+			fields: strudel.Fields{"type": "error", "data": strudel.Fields{"key": "value"}},
+			exp: map[string]interface{}{
+				"level": "ERROR",
+				"msg":   "error",
+				"type":  "error",
+				"data":  map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			l := slog.New(slog.NewJSONHandler(buf, nil))
+
+			strudel.NewSlogSink(l).Log(tt.level, tt.msg, tt.fields)
+
+			assertLogEntry(t, buf, tt.exp)
+		})
+	}
+}
+
+func assertLogEntry(t *testing.T, buf *bytes.Buffer, exp map[string]interface{}) {
+	t.Helper()
+
+	act := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &act); err != nil {
+		t.Errorf("got %v, expected nil", err)
+	}
+
+	delete(act, "time")
+
+	if !reflect.DeepEqual(act, exp) {
+		t.Errorf("got %v, expected %v", act, exp)
+	}
+}
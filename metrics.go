@@ -0,0 +1,332 @@
+package strudel
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/stevecallear/janice"
+)
+
+type (
+	// Metrics represents a set of HTTP request metrics
+	Metrics struct {
+		mu              sync.Mutex
+		durationBuckets []float64
+		sizeBuckets     []float64
+		route           func(*http.Request) string
+		requests        map[requestLabels]*requestMetrics
+		errors          map[string]uint64
+	}
+
+	// MetricsOption represents a metrics option
+	MetricsOption func(*Metrics)
+
+	requestLabels struct {
+		method string
+		class  string
+		route  string
+	}
+
+	requestMetrics struct {
+		count    uint64
+		duration *histogram
+		size     *histogram
+	}
+
+	histogram struct {
+		buckets []float64
+		counts  []uint64
+		sum     float64
+		count   uint64
+	}
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	// DefaultDurationBuckets are the default request duration histogram buckets in seconds
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// DefaultSizeBuckets are the default response size histogram buckets in bytes
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// NewMetrics returns a new set of metrics with the specified options
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		route:           routeLabel,
+		requests:        map[requestLabels]*requestMetrics{},
+		errors:          map[string]uint64{},
+	}
+
+	for _, o := range opts {
+		o(m)
+	}
+
+	return m
+}
+
+// WithDurationBuckets configures the request duration histogram buckets in seconds
+func WithDurationBuckets(b ...float64) MetricsOption {
+	return func(m *Metrics) {
+		m.durationBuckets = sortedBuckets(b)
+	}
+}
+
+// WithSizeBuckets configures the response size histogram buckets in bytes
+func WithSizeBuckets(b ...float64) MetricsOption {
+	return func(m *Metrics) {
+		m.sizeBuckets = sortedBuckets(b)
+	}
+}
+
+// WithRouteFunc configures the function used to resolve the route label for a request
+// The route template set using SetRoute is used by default
+func WithRouteFunc(fn func(*http.Request) string) MetricsOption {
+	return func(m *Metrics) {
+		m.route = fn
+	}
+}
+
+// Middleware is a metrics middleware function
+// It should be placed inside ErrorHandling so that returned errors are recorded
+// Panics are recorded as internal server errors and are not recovered
+func (m *Metrics) Middleware(n janice.HandlerFunc) janice.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		r = withHolder(r)
+
+		var (
+			mt   httpsnoop.Metrics
+			err  error
+			done bool
+		)
+
+		defer func() {
+			// the panic is not recovered so that the panic stack is preserved for Recovery
+			if !done {
+				m.observeRequest(m.labels(r, http.StatusInternalServerError), mt.Duration.Seconds(), float64(mt.Written))
+			}
+		}()
+
+		captureMetrics(&mt, w, func(ww http.ResponseWriter) {
+			err = n(ww, r)
+		})
+		done = true
+
+		code := mt.Code
+		if err != nil {
+			code = http.StatusInternalServerError
+
+			if se, ok := AsError(err); ok {
+				code = se.StatusCode()
+				m.observeError(se)
+			}
+		}
+
+		m.observeRequest(m.labels(r, code), mt.Duration.Seconds(), float64(mt.Written))
+
+		return err
+	}
+}
+
+func (m *Metrics) labels(r *http.Request, code int) requestLabels {
+	return requestLabels{
+		method: r.Method,
+		class:  strconv.Itoa(code/100) + "xx",
+		route:  m.route(r),
+	}
+}
+
+// captureMetrics calls fn with a wrapped response writer, updating the metrics as the response is written
+// Unlike httpsnoop.CaptureMetricsFn the metrics are available if fn panics
+func captureMetrics(mt *httpsnoop.Metrics, w http.ResponseWriter, fn func(http.ResponseWriter)) {
+	start := time.Now()
+	defer func() {
+		mt.Duration = time.Since(start)
+	}()
+
+	mt.Code = http.StatusOK
+	headerWritten := false
+
+	fn(httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				next(code)
+
+				if !headerWritten {
+					mt.Code = code
+					headerWritten = true
+				}
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				n, err := next(b)
+
+				mt.Written += int64(n)
+				headerWritten = true
+				return n, err
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				n, err := next(src)
+
+				mt.Written += n
+				headerWritten = true
+				return n, err
+			}
+		},
+	}))
+}
+
+// routeLabel returns the route template set using SetRoute, or an empty string
+func routeLabel(r *http.Request) string {
+	rt, _ := GetRoute(r)
+	return rt
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestLabels, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		if a.class != b.class {
+			return a.class < b.class
+		}
+		return a.route < b.route
+	})
+
+	var sb strings.Builder
+
+	writeMetricHeader(&sb, "http_requests_total", "counter", "Total number of HTTP requests.")
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "http_requests_total{%s} %d\n", k.String(), m.requests[k].count)
+	}
+
+	writeMetricHeader(&sb, "http_request_duration_seconds", "histogram", "HTTP request duration in seconds.")
+	for _, k := range keys {
+		m.requests[k].duration.writeTo(&sb, "http_request_duration_seconds", k.String())
+	}
+
+	writeMetricHeader(&sb, "http_response_size_bytes", "histogram", "HTTP response size in bytes.")
+	for _, k := range keys {
+		m.requests[k].size.writeTo(&sb, "http_response_size_bytes", k.String())
+	}
+
+	codes := make([]string, 0, len(m.errors))
+	for c := range m.errors {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+
+	writeMetricHeader(&sb, "strudel_errors_total", "counter", "Total number of strudel errors by status code.")
+	for _, c := range codes {
+		fmt.Fprintf(&sb, "strudel_errors_total{code=%s} %d\n", quoteLabel(c), m.errors[c])
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (m *Metrics) observeRequest(l requestLabels, duration, size float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm, ok := m.requests[l]
+	if !ok {
+		rm = &requestMetrics{
+			duration: newHistogram(m.durationBuckets),
+			size:     newHistogram(m.sizeBuckets),
+		}
+		m.requests[l] = rm
+	}
+
+	rm.count++
+	rm.duration.observe(duration)
+	rm.size.observe(size)
+}
+
+func (m *Metrics) observeError(err *Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errors[strconv.Itoa(err.StatusCode())]++
+}
+
+// String returns the labels in the Prometheus text exposition format
+func (l requestLabels) String() string {
+	return fmt.Sprintf("method=%s,status=%s,route=%s", quoteLabel(l.method), quoteLabel(l.class), quoteLabel(l.route))
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) writeTo(sb *strings.Builder, name, labels string) {
+	for i, b := range h.buckets {
+		fmt.Fprintf(sb, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(b), h.counts[i])
+	}
+
+	fmt.Fprintf(sb, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(sb, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(sb, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func writeMetricHeader(sb *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func quoteLabel(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedBuckets(b []float64) []float64 {
+	s := append([]float64(nil), b...)
+	sort.Float64s(s)
+
+	return s
+}
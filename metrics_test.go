@@ -0,0 +1,222 @@
+package strudel_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/janice"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []strudel.MetricsOption
+		method   string
+		fn       janice.HandlerFunc
+		contains []string
+	}{
+		{
+			name:   "should count requests by method and status class",
+			method: "POST",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				w.WriteHeader(http.StatusCreated)
+				return nil
+			},
+			contains: []string{
+				`http_requests_total{method="POST",status="2xx",route=""} 1`,
+				`http_request_duration_seconds_count{method="POST",status="2xx",route=""} 1`,
+				`http_response_size_bytes_count{method="POST",status="2xx",route=""} 1`,
+			},
+		},
+		{
+			name:   "should record response size",
+			method: "GET",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				fmt.Fprint(w, strings.Repeat("a", 150))
+				return nil
+			},
+			contains: []string{
+				`http_response_size_bytes_bucket{method="GET",status="2xx",route="",le="100"} 0`,
+				`http_response_size_bytes_bucket{method="GET",status="2xx",route="",le="1000"} 1`,
+				`http_response_size_bytes_bucket{method="GET",status="2xx",route="",le="+Inf"} 1`,
+				`http_response_size_bytes_sum{method="GET",status="2xx",route=""} 150`,
+			},
+		},
+		{
+			name:   "should use the configured buckets",
+			opts:   []strudel.MetricsOption{strudel.WithDurationBuckets(60, 30), strudel.WithSizeBuckets(1)},
+			method: "GET",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return nil
+			},
+			contains: []string{
+				`http_request_duration_seconds_bucket{method="GET",status="2xx",route="",le="30"} 1`,
+				`http_request_duration_seconds_bucket{method="GET",status="2xx",route="",le="60"} 1`,
+				`http_response_size_bytes_bucket{method="GET",status="2xx",route="",le="1"} 1`,
+			},
+		},
+		{
+			name: "should use the configured route",
+			opts: []strudel.MetricsOption{strudel.WithRouteFunc(func(*http.Request) string {
+				return `/orders/{id}`
+			})},
+			method: "GET",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return nil
+			},
+			contains: []string{
+				`http_requests_total{method="GET",status="2xx",route="/orders/{id}"} 1`,
+			},
+		},
+		{
+			name:   "should use the request route by default",
+			method: "GET",
+			fn: func(w http.ResponseWriter, r *http.Request) error {
+				strudel.SetRoute(r, "/orders/{id}")
+				return nil
+			},
+			contains: []string{
+				`http_requests_total{method="GET",status="2xx",route="/orders/{id}"} 1`,
+			},
+		},
+		{
+			name:   "should count errors by code",
+			method: "GET",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return fmt.Errorf("context: %w", strudel.NewError("error").WithCode(http.StatusNotFound))
+			},
+			contains: []string{
+				`http_requests_total{method="GET",status="4xx",route=""} 1`,
+				`strudel_errors_total{code="404"} 1`,
+			},
+		},
+		{
+			name:   "should count errors without a code as 500",
+			method: "GET",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return strudel.NewError("error")
+			},
+			contains: []string{
+				`http_requests_total{method="GET",status="5xx",route=""} 1`,
+				`strudel_errors_total{code="500"} 1`,
+			},
+		},
+		{
+			name:   "should record other errors as 5xx",
+			method: "GET",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return errors.New("error")
+			},
+			contains: []string{
+				`http_requests_total{method="GET",status="5xx",route=""} 1`,
+				"# TYPE strudel_errors_total counter\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := strudel.NewMetrics(tt.opts...)
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest(tt.method, "/", nil)
+			m.Middleware(tt.fn)(rec, req)
+
+			rec, req = httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil)
+			m.ServeHTTP(rec, req)
+
+			if act, exp := rec.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; act != exp {
+				t.Errorf("got %s, expected %s", act, exp)
+			}
+
+			body := rec.Body.String()
+			for _, c := range tt.contains {
+				if !strings.Contains(body, c) {
+					t.Errorf("got %s, expected to contain %s", body, c)
+				}
+			}
+		})
+	}
+}
+
+func TestMetrics_Panic(t *testing.T) {
+	t.Run("should record panics as 5xx", func(t *testing.T) {
+		restoreLogger := setLogger(io.Discard)
+		defer restoreLogger()
+
+		m := strudel.NewMetrics()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		janice.New(strudel.Recovery, m.Middleware).Then(func(http.ResponseWriter, *http.Request) error {
+			panic("error")
+		}).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("got %d, expected %d", rec.Code, http.StatusInternalServerError)
+		}
+
+		rec, req = httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil)
+		m.ServeHTTP(rec, req)
+
+		body := rec.Body.String()
+		for _, c := range []string{
+			`http_requests_total{method="GET",status="5xx",route=""} 1`,
+			`http_request_duration_seconds_count{method="GET",status="5xx",route=""} 1`,
+			`http_response_size_bytes_count{method="GET",status="5xx",route=""} 1`,
+		} {
+			if !strings.Contains(body, c) {
+				t.Errorf("got %s, expected to contain %s", body, c)
+			}
+		}
+	})
+
+	t.Run("should not recover the panic", func(t *testing.T) {
+		defer func() {
+			if rec := recover(); rec != "error" {
+				t.Errorf("got %v, expected error", rec)
+			}
+		}()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		strudel.NewMetrics().Middleware(func(http.ResponseWriter, *http.Request) error {
+			panic("error")
+		})(rec, req)
+	})
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	t.Run("should escape label values", func(t *testing.T) {
+		m := strudel.NewMetrics(strudel.WithRouteFunc(func(*http.Request) string {
+			return "a\"b\\c\nd"
+		}))
+
+		m.Middleware(func(http.ResponseWriter, *http.Request) error {
+			return nil
+		})(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		srv := httptest.NewServer(m)
+		defer srv.Close()
+
+		res, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		defer res.Body.Close()
+
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		exp := `http_requests_total{method="GET",status="2xx",route="a\"b\\c\nd"} 1`
+		if !strings.Contains(string(b), exp) {
+			t.Errorf("got %s, expected to contain %s", b, exp)
+		}
+	})
+}
@@ -2,136 +2,575 @@ package strudel
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/felixge/httpsnoop"
-	"github.com/gamegos/jsend"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/stevecallear/janice"
 )
 
+type (
+	// Middleware represents a set of middleware functions bound to a logger and settings
+	Middleware struct {
+		logger          LogSink
+		encoder         ErrorEncoder
+		encoders        []ErrorEncoder
+		localizer       MessageLocalizer
+		redaction       []RedactionRule
+		scrubParams     []string
+		scrubPath       []string
+		splitQuery      bool
+		requestIDHeader *string
+		validRequestID  func(string) bool
+		newRequestID    IDGenerator
+	}
+
+	// Option represents a middleware option
+	Option func(*Middleware)
+
+	contextKey string
+)
+
+const maxRequestIDLength = 128
+
+// entityHeaders are the response headers that are removed before a recovered panic is written
+var entityHeaders = []string{
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Location",
+	"Content-Range",
+	"Content-Type",
+	"ETag",
+	"Last-Modified",
+}
+
 var (
-	// Logger is the logger used for all middleware
+	// Logger is the logger used by middleware that has not been configured with a log sink
 	Logger *logrus.Logger
 
+	// Encoder is the default encoder used to write error responses
+	// It is used if the request does not accept any registered encoder
+	Encoder ErrorEncoder = JSend
+
+	// RequestIDHeader is the header used to accept and return request ids
+	// Inbound request ids are ignored if it is empty
+	RequestIDHeader = "X-Request-ID"
+
+	// NewRequestID is the generator used to create request ids
+	NewRequestID = UUIDv4
+
+	// ValidRequestID returns true if the specified inbound request id can be used
+	ValidRequestID = func(id string) bool {
+		if len(id) < 1 || len(id) > maxRequestIDLength {
+			return false
+		}
+
+		for _, c := range id {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+				return false
+			}
+		}
+
+		return true
+	}
+
 	// GetRequestID returns the id for the specified request
+	// The id is also visible to outer middleware that prepared the request using SetTraceContext
 	GetRequestID = func(r *http.Request) (string, bool) {
-		v, _ := r.Context().Value(reqIDKey).(string)
-		return v, v != ""
+		if v, _ := r.Context().Value(reqIDKey).(string); v != "" {
+			return v, true
+		}
+
+		if h, ok := getHolder(r); ok {
+			v, _ := h.requestID.Load().(string)
+			return v, v != ""
+		}
+
+		return "", false
 	}
 
 	reqIDKey = contextKey("requestid")
-)
 
-type contextKey string
+	std = New()
+)
 
 func init() {
 	Logger = logrus.New()
 	Logger.Formatter = new(logrus.JSONFormatter)
 }
 
+// New returns a new middleware set with the specified options
+// Any settings that are not specified use the package level defaults
+func New(opts ...Option) *Middleware {
+	m := new(Middleware)
+	for _, o := range opts {
+		o(m)
+	}
+
+	return m
+}
+
+// WithLogger configures the middleware log sink
+func WithLogger(l LogSink) Option {
+	return func(m *Middleware) {
+		m.logger = l
+	}
+}
+
+// WithEncoder configures the default error encoder
+func WithEncoder(e ErrorEncoder) Option {
+	return func(m *Middleware) {
+		m.encoder = e
+	}
+}
+
+// WithEncoders configures the error encoders used for content negotiation
+func WithEncoders(e ...ErrorEncoder) Option {
+	return func(m *Middleware) {
+		m.encoders = e
+	}
+}
+
+// WithLocalizer configures the localizer used to write client error messages
+func WithLocalizer(l MessageLocalizer) Option {
+	return func(m *Middleware) {
+		m.localizer = l
+	}
+}
+
+// WithRedaction configures the rules used to redact log entries
+// DefaultRedactionRules are used if no rules are specified
+func WithRedaction(rules ...RedactionRule) Option {
+	return func(m *Middleware) {
+		if len(rules) < 1 {
+			rules = DefaultRedactionRules
+		}
+
+		m.redaction = rules
+	}
+}
+
+// WithQueryScrubbing configures the query parameter names that are masked in request logs
+func WithQueryScrubbing(names ...string) Option {
+	return func(m *Middleware) {
+		m.scrubParams = names
+	}
+}
+
+// WithPathScrubbing configures the route template parameter names that are masked in request logs
+// Paths are only scrubbed if the route template has been set using SetRoute
+func WithPathScrubbing(names ...string) Option {
+	return func(m *Middleware) {
+		m.scrubPath = names
+	}
+}
+
+// WithSplitQuery configures request logs to write the path and query as separate fields
+func WithSplitQuery() Option {
+	return func(m *Middleware) {
+		m.splitQuery = true
+	}
+}
+
+// WithRequestIDHeader configures the header used to accept and return request ids
+// Inbound request ids are ignored and not returned if the header is empty
+func WithRequestIDHeader(name string) Option {
+	return func(m *Middleware) {
+		m.requestIDHeader = &name
+	}
+}
+
+// WithRequestIDValidator configures the function used to validate inbound request ids
+func WithRequestIDValidator(fn func(string) bool) Option {
+	return func(m *Middleware) {
+		m.validRequestID = fn
+	}
+}
+
+// WithRequestIDGenerator configures the generator used to create request ids
+func WithRequestIDGenerator(g IDGenerator) Option {
+	return func(m *Middleware) {
+		m.newRequestID = g
+	}
+}
+
 // RequestTracking is a request tracking middleware function
 func RequestTracking(n janice.HandlerFunc) janice.HandlerFunc {
+	return std.RequestTracking(n)
+}
+
+// RequestLogging is a request logging middleware function
+func RequestLogging(n janice.HandlerFunc) janice.HandlerFunc {
+	return std.RequestLogging(n)
+}
+
+// Recovery is a panic recovery middleware function
+func Recovery(n janice.HandlerFunc) janice.HandlerFunc {
+	return std.Recovery(n)
+}
+
+// ErrorHandling is an error handling middleware function
+func ErrorHandling(n janice.HandlerFunc) janice.HandlerFunc {
+	return std.ErrorHandling(n)
+}
+
+// Success writes a success response with the specified status and data
+func Success(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	return std.Success(w, r, status, data)
+}
+
+// Fail writes a client failure response with the specified status and data
+func Fail(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	return std.Fail(w, r, status, data)
+}
+
+// RequestTracking is a request tracking middleware function
+// The inbound request id header is used if it is valid, otherwise a new id is generated
+// If the request has a valid W3C traceparent header then a child span is added to the trace context,
+// unless the trace context has already been set using SetTraceContext
+// The request is also prepared so that routers can set the route template using SetRoute
+func (m *Middleware) RequestTracking(n janice.HandlerFunc) janice.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		id := uuid.NewString()
-		ctx := context.WithValue(r.Context(), reqIDKey, id)
+		h := RequestIDHeader
+		if m.requestIDHeader != nil {
+			h = *m.requestIDHeader
+		}
+
+		valid := m.validRequestID
+		if valid == nil {
+			valid = ValidRequestID
+		}
+
+		id := r.Header.Get(h)
+		if !valid(id) {
+			if m.newRequestID != nil {
+				id = m.newRequestID()
+			} else {
+				id = NewRequestID()
+			}
+		}
+
+		if h != "" {
+			w.Header().Set(h, id)
+		}
+
+		ctx := holderContext(context.WithValue(r.Context(), reqIDKey, id))
+		ctx.Value(holderKey).(*requestHolder).requestID.Store(id)
+
+		if _, ok := ctx.Value(traceKey).(TraceContext); !ok {
+			if tc, ok := childTraceContext(r); ok {
+				ctx = context.WithValue(ctx, traceKey, tc)
+			}
+		}
 
 		return n(w, r.WithContext(ctx))
 	}
 }
 
 // RequestLogging is a request logging middleware function
-func RequestLogging(n janice.HandlerFunc) janice.HandlerFunc {
+// Matching query and path parameter values are masked, and the query is written as a separate field if configured
+// The route field is written if the route template has been set using SetRoute
+func (m *Middleware) RequestLogging(n janice.HandlerFunc) janice.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		p := r.URL.String()
+		u := *r.URL
+		r = withHolder(r)
 
 		var err error
-		m := httpsnoop.CaptureMetricsFn(w, func(ww http.ResponseWriter) {
+		mt := httpsnoop.CaptureMetricsFn(w, func(ww http.ResponseWriter) {
 			err = n(ww, r)
 		})
 
-		le := Logger.WithFields(logrus.Fields{
+		rt, hasRoute := GetRoute(r)
+		p, q := m.requestPath(u, rt)
+
+		f := Fields{
 			"type":     "request",
 			"host":     r.Host,
 			"method":   r.Method,
 			"path":     p,
-			"code":     m.Code,
-			"duration": m.Duration.String(),
-			"written":  m.Written,
-		})
+			"code":     mt.Code,
+			"duration": mt.Duration.String(),
+			"written":  mt.Written,
+		}
+
+		if len(q) > 0 {
+			f["query"] = q
+		}
 
-		if rid, ok := GetRequestID(r); ok {
-			le = le.WithField("request", rid)
+		if hasRoute {
+			f["route"] = rt
 		}
 
-		le.Info()
+		m.log().Log(InfoLevel, "", requestFields(r, f))
 
 		return err
 	}
 }
 
 // Recovery is a panic recovery middleware function
-func Recovery(n janice.HandlerFunc) janice.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) error {
+// The panic is written as an error response if the response has not been committed,
+// otherwise the connection is aborted. http.ErrAbortHandler panics are not recovered.
+func (m *Middleware) Recovery(n janice.HandlerFunc) janice.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) (err error) {
+		cw, committed := trackCommit(w)
+
 		defer func() {
 			if rec := recover(); rec != nil {
-				le := Logger.WithField("type", "recovery")
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
 
-				if rid, ok := GetRequestID(r); ok {
-					le = le.WithField("request", rid)
+				st := panicStack()
+				f := Fields{
+					"type":  "recovery",
+					"stack": st,
 				}
 
-				le.Error(rec)
+				re := recoveryError(rec, st)
+				setHandledError(r, re)
+
+				if *committed {
+					f["committed"] = true
+					f["panic"] = fmt.Sprint(rec)
 
-				w.WriteHeader(http.StatusInternalServerError)
+					m.log().Log(ErrorLevel, "panic after response committed", requestFields(r, f))
+					panic(http.ErrAbortHandler)
+				}
+
+				m.log().Log(ErrorLevel, fmt.Sprint(rec), requestFields(r, f))
+
+				clearEntityHeaders(w.Header())
+				err = m.encode(w, r, re)
 			}
 		}()
 
-		return n(w, r)
+		return n(cw, r)
 	}
 }
 
 // ErrorHandling is an error handling middleware function
-func ErrorHandling(n janice.HandlerFunc) janice.HandlerFunc {
+// The error response is not written if the response has already been committed
+func (m *Middleware) ErrorHandling(n janice.HandlerFunc) janice.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		if err := n(w, r); err != nil {
-			le := Logger.WithField("type", "error")
+		cw, committed := trackCommit(w)
 
-			jw := jsend.Wrap(w).
-				Status(http.StatusInternalServerError).
-				Message(http.StatusText(http.StatusInternalServerError))
+		if err := n(cw, r); err != nil {
+			f := Fields{"type": "error"}
 
-			if err, ok := err.(*Error); ok {
-				c := err.Code()
-				if c > 0 {
-					le = le.WithField("code", c)
-				}
-				if c >= 400 && c < 600 {
-					jw = jw.Status(c)
-				}
+			se, ok := AsError(err)
+			if !ok {
+				se = newBareError(http.StatusText(http.StatusInternalServerError), nil)
+			}
 
-				if f := err.Fields(); len(f) > 0 {
-					jw = jw.Data(f)
-				}
+			setHandledError(r, se)
 
-				if lf := err.LogFields(); len(lf) > 0 {
-					le = le.WithField("data", lf)
-				}
+			if c := se.Code(); c > 0 {
+				f["code"] = c
+			}
+
+			if ac := se.AppCode(); ac != "" {
+				f["error_code"] = ac
+			}
+
+			if lf := se.LogFields(); len(lf) > 0 {
+				f["data"] = lf
+			}
 
-				jw = jw.Message(err.Error())
+			if st := se.Stack(); len(st) > 0 {
+				f["stack"] = st
 			}
 
-			if rid, ok := GetRequestID(r); ok {
-				le = le.WithField("request", rid)
+			if *committed {
+				f["committed"] = true
 			}
 
-			le.Error(err.Error())
+			m.log().Log(ErrorLevel, err.Error(), requestFields(r, f))
 
-			_, err := jw.Send()
-			return err
+			if *committed {
+				return nil
+			}
+
+			return m.encode(w, r, se)
 		}
 
 		return nil
 	}
 }
+
+// Success writes a success response with the specified status and data
+// The response is written by the negotiated DataEncoder, status 200 is used if the status is not 2xx
+func (m *Middleware) Success(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	if status < 200 || status > 299 {
+		status = http.StatusOK
+	}
+
+	return m.encodeData(w, r, status, data)
+}
+
+// Fail writes a client failure response with the specified status and data
+// The response is written by the negotiated DataEncoder, status 400 is used if the status is not 4xx
+func (m *Middleware) Fail(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	if status < 400 || status > 499 {
+		status = http.StatusBadRequest
+	}
+
+	return m.encodeData(w, r, status, data)
+}
+
+// requestPath returns the request path to be logged, along with the query fields if the query is split
+func (m *Middleware) requestPath(u url.URL, route string) (string, map[string]interface{}) {
+	pathNames := m.scrubPath
+	if pathNames == nil {
+		pathNames = ScrubPathParams
+	}
+
+	scrubPath(&u, route, pathNames)
+
+	names := m.scrubParams
+	if names == nil {
+		names = ScrubQueryParams
+	}
+
+	if m.splitQuery || SplitQuery {
+		q := u.RawQuery
+		u.RawQuery, u.ForceQuery = "", false
+		return u.String(), queryFields(q, names)
+	}
+
+	u.RawQuery = scrubQuery(u.RawQuery, names)
+	return u.String(), nil
+}
+
+func (m *Middleware) log() LogSink {
+	l := m.logger
+	if l == nil {
+		l = NewLogrusSink(Logger)
+	}
+
+	rules := m.redaction
+	if rules == nil {
+		rules = RedactionRules
+	}
+
+	if len(rules) > 0 {
+		l = NewRedactingSink(l, rules...)
+	}
+
+	return l
+}
+
+func (m *Middleware) encode(w http.ResponseWriter, r *http.Request, err *Error) error {
+	def, encs := m.encoderConfig()
+
+	l := m.localizer
+	if l == nil {
+		l = Localizer
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	return negotiateEncoder(r, def, encs).Encode(w, r, localize(l, w, r, err))
+}
+
+// encodeData writes the data using the configured encoders that implement DataEncoder
+// JSend is used as the default if the configured default encoder does not implement DataEncoder
+func (m *Middleware) encodeData(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	def, encs := m.encoderConfig()
+	if _, ok := def.(DataEncoder); !ok {
+		def = JSend
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	return negotiateEncoder(r, def, dataEncoders(encs)).(DataEncoder).EncodeData(w, r, status, data)
+}
+
+func (m *Middleware) encoderConfig() (ErrorEncoder, []ErrorEncoder) {
+	def := m.encoder
+	if def == nil {
+		def = Encoder
+	}
+
+	encs := m.encoders
+	if encs == nil {
+		encs = registeredEncoders()
+	}
+
+	return def, encs
+}
+
+// requestFields adds the request id and trace context to the specified log fields
+func requestFields(r *http.Request, f Fields) Fields {
+	if rid, ok := GetRequestID(r); ok {
+		f["request"] = rid
+	}
+
+	if tc, ok := GetTraceContext(r); ok {
+		f["trace_id"] = tc.TraceID
+		f["span_id"] = tc.SpanID
+	}
+
+	return f
+}
+
+// recoveryError returns an internal server error for the specified panic value and stack
+func recoveryError(rec interface{}, st []Frame) *Error {
+	cause, ok := rec.(error)
+	if !ok {
+		cause = fmt.Errorf("%v", rec)
+	}
+
+	e := newBareError(http.StatusText(http.StatusInternalServerError), cause).
+		WithCode(http.StatusInternalServerError)
+
+	e.stack = st
+	e.recovered = true
+
+	return e
+}
+
+// clearEntityHeaders removes the headers that describe the handler response body
+// They would otherwise be written with the error response, which can corrupt the error body
+func clearEntityHeaders(h http.Header) {
+	for _, k := range entityHeaders {
+		h.Del(k)
+	}
+}
+
+// trackCommit returns a response writer that records whether the response has been committed
+func trackCommit(w http.ResponseWriter) (http.ResponseWriter, *bool) {
+	committed := new(bool)
+
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				*committed = true
+				next(code)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				*committed = true
+				return next(b)
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				*committed = true
+				return next(src)
+			}
+		},
+		Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+			return func() {
+				*committed = true
+				next()
+			}
+		},
+	}), committed
+}
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	stdlog "log"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -18,27 +21,191 @@ import (
 	"github.com/stevecallear/strudel"
 )
 
-func TestRequestTracking(t *testing.T) {
-	t.Run("should set the request id", func(t *testing.T) {
-		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+func TestNew(t *testing.T) {
+	t.Run("should use the configured logger", func(t *testing.T) {
+		gbuf, buf := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
 
-		err := strudel.RequestTracking(func(w http.ResponseWriter, r *http.Request) error {
-			exp := regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
+		restoreLogger := setLogger(gbuf)
+		defer restoreLogger()
 
-			act, _ := strudel.GetRequestID(r)
+		l := logrus.New()
+		l.Formatter = new(logrus.JSONFormatter)
+		l.Out = buf
 
-			if !exp.MatchString(act) {
-				t.Errorf("got %s, expected a valid uuid", act)
-			}
+		m := strudel.New(strudel.WithLogger(strudel.NewLogrusSink(l)))
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
 
-			return nil
+		err := m.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+			return errors.New("error")
 		})(rec, req)
 		if err != nil {
 			t.Errorf("got %v, expected nil", err)
 		}
+
+		if buf.Len() < 1 {
+			t.Error("got empty log, expected entry")
+		}
+
+		if gbuf.Len() > 0 {
+			t.Errorf("got %s, expected empty package log", gbuf.String())
+		}
+	})
+
+	t.Run("should use the configured encoders", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			opts        []strudel.Option
+			accept      string
+			contentType string
+		}{
+			{
+				name:        "should use the default encoder",
+				opts:        []strudel.Option{strudel.WithEncoder(strudel.Problem)},
+				contentType: "application/problem+json",
+			},
+			{
+				name:        "should negotiate the configured encoders",
+				opts:        []strudel.Option{strudel.WithEncoders(strudel.Text)},
+				accept:      "text/plain",
+				contentType: "text/plain; charset=utf-8",
+			},
+			{
+				name:        "should not negotiate other encoders",
+				opts:        []strudel.Option{strudel.WithEncoders(strudel.Text)},
+				accept:      "application/problem+json",
+				contentType: "application/json",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				restoreLogger := setLogger(io.Discard)
+				defer restoreLogger()
+
+				rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+				if tt.accept != "" {
+					req.Header.Set("Accept", tt.accept)
+				}
+
+				err := strudel.New(tt.opts...).ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+					return errors.New("error")
+				})(rec, req)
+				if err != nil {
+					t.Errorf("got %v, expected nil", err)
+				}
+
+				if act := rec.Header().Get("Content-Type"); act != tt.contentType {
+					t.Errorf("got %s, expected %s", act, tt.contentType)
+				}
+			})
+		}
 	})
 }
 
+func TestRequestTracking(t *testing.T) {
+	uuidRx := regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
+
+	assertUUID := func(t *testing.T, id string) {
+		if !uuidRx.MatchString(id) {
+			t.Errorf("got %s, expected a valid uuid", id)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    []strudel.Option
+		headers map[string]string
+		header  string
+		assert  func(t *testing.T, id string)
+	}{
+		{
+			name:   "should set the request id",
+			header: "X-Request-ID",
+			assert: assertUUID,
+		},
+		{
+			name:    "should use the inbound request id",
+			headers: map[string]string{"X-Request-ID": "abc-123_4.5:6"},
+			header:  "X-Request-ID",
+			assert:  assertRequestID("abc-123_4.5:6"),
+		},
+		{
+			name:    "should generate an id if the inbound request id has invalid characters",
+			headers: map[string]string{"X-Request-ID": "abc 123"},
+			header:  "X-Request-ID",
+			assert:  assertUUID,
+		},
+		{
+			name:    "should generate an id if the inbound request id is too long",
+			headers: map[string]string{"X-Request-ID": strings.Repeat("a", 129)},
+			header:  "X-Request-ID",
+			assert:  assertUUID,
+		},
+		{
+			name:    "should use the configured header",
+			opts:    []strudel.Option{strudel.WithRequestIDHeader("X-Correlation-ID")},
+			headers: map[string]string{"X-Request-ID": "abc", "X-Correlation-ID": "def"},
+			header:  "X-Correlation-ID",
+			assert:  assertRequestID("def"),
+		},
+		{
+			name:    "should ignore the inbound request id if the configured header is empty",
+			opts:    []strudel.Option{strudel.WithRequestIDHeader("")},
+			headers: map[string]string{"X-Request-ID": "abc"},
+			assert:  assertUUID,
+		},
+		{
+			name: "should use the configured generator",
+			opts: []strudel.Option{strudel.WithRequestIDGenerator(func() string {
+				return "generated"
+			})},
+			header: "X-Request-ID",
+			assert: assertRequestID("generated"),
+		},
+		{
+			name: "should use the configured validator",
+			opts: []strudel.Option{strudel.WithRequestIDValidator(func(id string) bool {
+				return id == "a b"
+			})},
+			headers: map[string]string{"X-Request-ID": "a b"},
+			header:  "X-Request-ID",
+			assert:  assertRequestID("a b"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			var id string
+			err := strudel.New(tt.opts...).RequestTracking(func(w http.ResponseWriter, r *http.Request) error {
+				id, _ = strudel.GetRequestID(r)
+				return nil
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			tt.assert(t, id)
+
+			if tt.header == "" {
+				if act := rec.Header().Get("X-Request-ID"); act != "" {
+					t.Errorf("got %s, expected an empty header", act)
+				}
+				return
+			}
+
+			if act := rec.Header().Get(tt.header); act != id {
+				t.Errorf("got %s, expected %s", act, id)
+			}
+		})
+	}
+}
+
 func TestRequestLogging(t *testing.T) {
 	err := errors.New("error")
 
@@ -161,6 +328,191 @@ func TestRequestLogging(t *testing.T) {
 	}
 }
 
+func TestRequestLogging_Query(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []strudel.Option
+		scrub  []string
+		split  bool
+		target string
+		path   string
+		query  interface{}
+	}{
+		{
+			name:   "should log the query verbatim by default",
+			target: "/path?api_key=abc&id=1",
+			path:   "/path?api_key=abc&id=1",
+		},
+		{
+			name:   "should mask matching parameters in order",
+			opts:   []strudel.Option{strudel.WithQueryScrubbing("API_KEY", "x-amz-*")},
+			target: "/path?id=1&api_key=abc&X-Amz-Signature=def&id=2&api_key",
+			path:   "/path?id=1&api_key=[REDACTED]&X-Amz-Signature=[REDACTED]&id=2&api_key=[REDACTED]",
+		},
+		{
+			name:   "should match encoded parameter names",
+			opts:   []strudel.Option{strudel.WithQueryScrubbing("api key")},
+			target: "/path?api%20key=abc&q=a%20b",
+			path:   "/path?api%20key=[REDACTED]&q=a%20b",
+		},
+		{
+			name:   "should use the package scrubbed parameters",
+			scrub:  []string{"token"},
+			target: "/path?token=abc",
+			path:   "/path?token=[REDACTED]",
+		},
+		{
+			name:   "should log the query as a separate field",
+			opts:   []strudel.Option{strudel.WithSplitQuery(), strudel.WithQueryScrubbing("token")},
+			target: "/path?token=abc&id=1&id=2&q=a%20b",
+			path:   "/path",
+			query: map[string]interface{}{
+				"token": "[REDACTED]",
+				"id":    []interface{}{"1", "2"},
+				"q":     "a b",
+			},
+		},
+		{
+			name:   "should use the package split query setting",
+			split:  true,
+			target: "/path?id=1",
+			path:   "/path",
+			query:  map[string]interface{}{"id": "1"},
+		},
+		{
+			name:   "should not log empty queries",
+			opts:   []strudel.Option{strudel.WithSplitQuery()},
+			target: "/path?",
+			path:   "/path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, pq := strudel.ScrubQueryParams, strudel.SplitQuery
+			strudel.ScrubQueryParams, strudel.SplitQuery = tt.scrub, tt.split
+			defer func() {
+				strudel.ScrubQueryParams, strudel.SplitQuery = ps, pq
+			}()
+
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil)
+
+			err := strudel.New(tt.opts...).RequestLogging(func(http.ResponseWriter, *http.Request) error {
+				return nil
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			act := map[string]interface{}{}
+			if err = json.Unmarshal(buf.Bytes(), &act); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if act["path"] != tt.path {
+				t.Errorf("got %v, expected %s", act["path"], tt.path)
+			}
+
+			if !reflect.DeepEqual(act["query"], tt.query) {
+				t.Errorf("got %v, expected %v", act["query"], tt.query)
+			}
+		})
+	}
+}
+
+func TestRequestLogging_Path(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []strudel.Option
+		scrub  []string
+		route  string
+		target string
+		path   string
+	}{
+		{
+			name:   "should log the path verbatim by default",
+			route:  "/users/{email}",
+			target: "/users/user@example.com",
+			path:   "/users/user@example.com",
+		},
+		{
+			name:   "should not scrub the path if the route is not set",
+			opts:   []strudel.Option{strudel.WithPathScrubbing("email")},
+			target: "/users/user@example.com",
+			path:   "/users/user@example.com",
+		},
+		{
+			name:   "should mask matching route parameters",
+			opts:   []strudel.Option{strudel.WithPathScrubbing("EMAIL", "*_token")},
+			route:  "/users/{email}/orders/{id}/{reset_token:[a-z]+}",
+			target: "/users/user%40example.com/orders/1/abc?id=1",
+			path:   "/users/[REDACTED]/orders/1/[REDACTED]?id=1",
+		},
+		{
+			name:   "should mask remaining segments for wildcard parameters",
+			opts:   []strudel.Option{strudel.WithPathScrubbing("key")},
+			route:  "GET example.com/files/{key...}",
+			target: "/files/a/b/c",
+			path:   "/files/[REDACTED]",
+		},
+		{
+			name:   "should use the package scrubbed parameters",
+			scrub:  []string{"email"},
+			route:  "/users/{email}",
+			target: "/users/user@example.com?token=abc",
+			path:   "/users/[REDACTED]?token=abc",
+		},
+		{
+			name:   "should scrub the path and query",
+			opts:   []strudel.Option{strudel.WithPathScrubbing("email"), strudel.WithQueryScrubbing("token")},
+			route:  "/users/{email}",
+			target: "/users/user@example.com?token=abc",
+			path:   "/users/[REDACTED]?token=[REDACTED]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := strudel.ScrubPathParams
+			strudel.ScrubPathParams = tt.scrub
+			defer func() {
+				strudel.ScrubPathParams = ps
+			}()
+
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil)
+
+			err := strudel.New(tt.opts...).RequestLogging(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.route != "" {
+					strudel.SetRoute(r, tt.route)
+				}
+				return nil
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			act := map[string]interface{}{}
+			if err = json.Unmarshal(buf.Bytes(), &act); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if act["path"] != tt.path {
+				t.Errorf("got %v, expected %s", act["path"], tt.path)
+			}
+		})
+	}
+}
+
 func TestRecovery(t *testing.T) {
 	err := errors.New("error")
 
@@ -231,7 +583,7 @@ func TestRecovery(t *testing.T) {
 
 			err := strudel.Recovery(func(http.ResponseWriter, *http.Request) error {
 				return tt.fn()
-			})(rec, nil)
+			})(rec, httptest.NewRequest("GET", "/", nil))
 			if err != tt.err {
 				t.Errorf("got %v, expected %v", err, tt.err)
 			}
@@ -247,12 +599,148 @@ func TestRecovery(t *testing.T) {
 				}
 
 				delete(act, "time")
+
+				st, ok := act["stack"].([]interface{})
+				if !ok || len(st) < 1 {
+					t.Errorf("got %v, expected stack", act["stack"])
+				} else if f := st[0].(map[string]interface{}); !strings.Contains(f["function"].(string), "TestRecovery") {
+					t.Errorf("got %v, expected panicking function", f["function"])
+				}
+
+				delete(act, "stack")
 			}
 
 			if !reflect.DeepEqual(act, tt.exp) {
 				t.Errorf("got %v, expected %v", act, tt.exp)
 			}
+		})
+	}
+}
+
+func TestRecovery_Response(t *testing.T) {
+	t.Run("should write the error response", func(t *testing.T) {
+		restoreRequestID := setRequestID("requestId")
+		defer restoreRequestID()
+
+		restoreLogger := setLogger(io.Discard)
+		defer restoreLogger()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "application/problem+json")
+
+		err := strudel.Recovery(func(w http.ResponseWriter, _ *http.Request) error {
+			w.Header().Set("X-Key", "value")
+			w.Header().Set("Content-Length", "1024")
+			w.Header().Set("Content-Disposition", "attachment; filename=report.csv")
+			panic("error")
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		for _, k := range []string{"Content-Length", "Content-Disposition"} {
+			if act := rec.Header().Get(k); act != "" {
+				t.Errorf("got %s: %s, expected no header", k, act)
+			}
+		}
+
+		if act, exp := rec.Header().Get("X-Key"), "value"; act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+
+		assertResponse(t, rec, http.StatusInternalServerError, "application/problem+json", map[string]interface{}{
+			"type":     "about:blank",
+			"title":    http.StatusText(http.StatusInternalServerError),
+			"status":   float64(http.StatusInternalServerError),
+			"detail":   http.StatusText(http.StatusInternalServerError),
+			"instance": "requestId",
+		})
+	})
+
+	t.Run("should abort if the response has been committed", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+
+		restoreLogger := setLogger(buf)
+		defer restoreLogger()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		defer func() {
+			if act := recover(); act != http.ErrAbortHandler {
+				t.Errorf("got %v, expected %v", act, http.ErrAbortHandler)
+			}
+
+			if act, exp := rec.Body.String(), "data"; act != exp {
+				t.Errorf("got %s, expected %s", act, exp)
+			}
+
+			log := map[string]interface{}{}
+			if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if log["msg"] != "panic after response committed" || log["committed"] != true || log["panic"] != "error" {
+				t.Errorf("got %v, expected committed panic entry", log)
+			}
+		}()
+
+		strudel.Recovery(func(w http.ResponseWriter, _ *http.Request) error {
+			fmt.Fprint(w, "data")
+			panic("error")
+		})(rec, req)
+	})
+}
+
+func TestRecovery_Abort(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   janice.HandlerFunc
+		log  bool
+	}{
+		{
+			name: "should not recover abort handler panics",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				panic(http.ErrAbortHandler)
+			},
+		},
+		{
+			name: "should abort without superfluous write header",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				w.WriteHeader(http.StatusAccepted)
+				panic("error")
+			},
+			log: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			errBuf := new(syncBuffer)
+
+			srv := httptest.NewUnstartedServer(janice.New(strudel.Recovery).Then(tt.fn))
+			srv.Config.ErrorLog = stdlog.New(errBuf, "", 0)
+			srv.Start()
+
+			res, err := http.Get(srv.URL)
+			if err == nil {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
 
+			srv.Close()
+
+			if act := errBuf.String(); act != "" {
+				t.Errorf("got %s, expected no server errors", act)
+			}
+
+			if act := buf.Len() > 0; act != tt.log {
+				t.Errorf("got %v, expected %v", act, tt.log)
+			}
 		})
 	}
 }
@@ -331,6 +819,30 @@ func TestErrorHandling(t *testing.T) {
 				"msg":   "error",
 			},
 		},
+		{
+			name: "should write catalog definitions",
+			err: fmt.Errorf("loading order: %w", (&strudel.Definition{
+				Code:    "ORDER_NOT_FOUND",
+				Status:  http.StatusNotFound,
+				Message: "order not found",
+				DocURL:  "https://example.com/errors/order-not-found",
+			})),
+			code: http.StatusNotFound,
+			body: map[string]interface{}{
+				"status":    "fail",
+				"message":   "order not found",
+				"data":      nil,
+				"errorCode": "ORDER_NOT_FOUND",
+				"docUrl":    "https://example.com/errors/order-not-found",
+			},
+			log: map[string]interface{}{
+				"type":       "error",
+				"level":      "error",
+				"code":       http.StatusNotFound,
+				"error_code": "ORDER_NOT_FOUND",
+				"msg":        "loading order: order not found",
+			},
+		},
 		{
 			name: "should use status 5xx if specified as error code",
 			err:  strudel.NewError("error").WithCode(http.StatusServiceUnavailable),
@@ -379,6 +891,37 @@ func TestErrorHandling(t *testing.T) {
 				"msg":     "error",
 			},
 		},
+		{
+			name: "should handle wrapped errors",
+			err:  fmt.Errorf("context: %w", strudel.NewError("error").WithCode(http.StatusNotFound)),
+			code: http.StatusNotFound,
+			body: map[string]interface{}{
+				"status":  "fail",
+				"message": "error",
+				"data":    nil,
+			},
+			log: map[string]interface{}{
+				"type":  "error",
+				"level": "error",
+				"code":  http.StatusNotFound,
+				"msg":   "context: error",
+			},
+		},
+		{
+			name: "should not write the cause to the body",
+			err:  strudel.Wrap(errors.New("cause"), "error").WithCode(http.StatusServiceUnavailable),
+			code: http.StatusServiceUnavailable,
+			body: map[string]interface{}{
+				"status":  "error",
+				"message": "error",
+			},
+			log: map[string]interface{}{
+				"type":  "error",
+				"level": "error",
+				"code":  http.StatusServiceUnavailable,
+				"msg":   "error: cause",
+			},
+		},
 		{
 			name: "should write error fields to log and body",
 			err:  strudel.NewError("error").WithField("key", "value"),
@@ -426,7 +969,7 @@ func TestErrorHandling(t *testing.T) {
 
 			err := strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
 				return tt.err
-			})(rec, nil)
+			})(rec, httptest.NewRequest("GET", "/", nil))
 			if err != nil {
 				t.Errorf("got %v, expected nil", err)
 			}
@@ -466,6 +1009,511 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorHandling_Committed(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   janice.HandlerFunc
+		code int
+		body string
+	}{
+		{
+			name: "should not write the error if the header has been written",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				w.WriteHeader(http.StatusAccepted)
+				return errors.New("error")
+			},
+			code: http.StatusAccepted,
+		},
+		{
+			name: "should not write the error if the body has been written",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				fmt.Fprint(w, "data")
+				return strudel.NewError("error").WithCode(http.StatusNotFound)
+			},
+			code: http.StatusOK,
+			body: "data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.ErrorHandling(tt.fn)(rec, req); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if rec.Code != tt.code {
+				t.Errorf("got %d, expected %d", rec.Code, tt.code)
+			}
+
+			if act := rec.Body.String(); act != tt.body {
+				t.Errorf("got %s, expected %s", act, tt.body)
+			}
+
+			log := map[string]interface{}{}
+			if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if log["committed"] != true || log["msg"] != "error" {
+				t.Errorf("got %v, expected committed error entry", log)
+			}
+		})
+	}
+}
+
+func TestErrorHandling_Stack(t *testing.T) {
+	t.Run("should log the stack and not write it to the body", func(t *testing.T) {
+		restoreCaptureStack := setCaptureStack(true)
+		defer restoreCaptureStack()
+
+		buf := bytes.NewBuffer(nil)
+
+		restoreLogger := setLogger(buf)
+		defer restoreLogger()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		err := strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+			return strudel.NewError("error")
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		log := map[string]interface{}{}
+		if err = json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if st, ok := log["stack"].([]interface{}); !ok || len(st) < 1 {
+			t.Errorf("got %v, expected stack", log["stack"])
+		}
+
+		if strings.Contains(rec.Body.String(), "stack") {
+			t.Errorf("got %s, expected no stack", rec.Body.String())
+		}
+	})
+
+	t.Run("should not log the middleware stack for other errors", func(t *testing.T) {
+		restoreCaptureStack := setCaptureStack(true)
+		defer restoreCaptureStack()
+
+		buf := bytes.NewBuffer(nil)
+
+		restoreLogger := setLogger(buf)
+		defer restoreLogger()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		err := strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+			return errors.New("error")
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		log := map[string]interface{}{}
+		if err = json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if st, ok := log["stack"]; ok {
+			t.Errorf("got %v, expected no stack", st)
+		}
+	})
+
+	t.Run("should not log the middleware stack for returned definitions", func(t *testing.T) {
+		restoreCaptureStack := setCaptureStack(true)
+		defer restoreCaptureStack()
+
+		buf := bytes.NewBuffer(nil)
+
+		restoreLogger := setLogger(buf)
+		defer restoreLogger()
+
+		d := strudel.NewCatalog().Register(strudel.Definition{
+			Code:    "ORDER_NOT_FOUND",
+			Status:  http.StatusNotFound,
+			Message: "order not found",
+		})
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		err := strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+			return d
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		log := map[string]interface{}{}
+		if err = json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if st, ok := log["stack"]; ok {
+			t.Errorf("got %v, expected no stack", st)
+		}
+
+		if act, exp := log["error_code"], "ORDER_NOT_FOUND"; act != exp {
+			t.Errorf("got %v, expected %s", act, exp)
+		}
+	})
+}
+
+func TestErrorHandling_Encoder(t *testing.T) {
+	t.Run("should use the configured encoder", func(t *testing.T) {
+		pe := strudel.Encoder
+		strudel.Encoder = strudel.Problem
+		defer func() {
+			strudel.Encoder = pe
+		}()
+
+		restoreRequestID := setRequestID("requestId")
+		defer restoreRequestID()
+
+		restoreLogger := setLogger(io.Discard)
+		defer restoreLogger()
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		err := strudel.ErrorHandling(func(http.ResponseWriter, *http.Request) error {
+			return strudel.NewError("error").WithCode(http.StatusNotFound)
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		assertResponse(t, rec, http.StatusNotFound, "application/problem+json", map[string]interface{}{
+			"type":     "about:blank",
+			"title":    http.StatusText(http.StatusNotFound),
+			"status":   float64(http.StatusNotFound),
+			"detail":   "error",
+			"instance": "requestId",
+		})
+	})
+}
+
+func TestErrorHandling_Negotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		handlerType string
+		contentType string
+	}{
+		{
+			name:        "should use the default encoder if accept is not set",
+			contentType: "application/json",
+		},
+		{
+			name:        "should use the default encoder if no encoder is acceptable",
+			accept:      "application/xml",
+			contentType: "application/json",
+		},
+		{
+			name:        "should use the acceptable encoder",
+			accept:      "application/problem+json",
+			contentType: "application/problem+json",
+		},
+		{
+			name:        "should use the default encoder for browser requests",
+			accept:      "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			contentType: "application/json",
+		},
+		{
+			name:        "should overwrite the handler content type",
+			handlerType: "text/csv",
+			contentType: "application/json",
+		},
+		{
+			name:        "should overwrite the handler content type for the acceptable encoder",
+			accept:      "application/problem+json",
+			handlerType: "text/csv",
+			contentType: "application/problem+json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreLogger := setLogger(io.Discard)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			err := strudel.ErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.handlerType != "" {
+					w.Header().Set("Content-Type", tt.handlerType)
+				}
+
+				return strudel.NewError("error")
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if act := rec.Header().Get("Content-Type"); act != tt.contentType {
+				t.Errorf("got %s, expected %s", act, tt.contentType)
+			}
+
+			if act, exp := rec.Header().Get("Vary"), "Accept"; act != exp {
+				t.Errorf("got %s, expected %s", act, exp)
+			}
+		})
+	}
+}
+
+func TestSuccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		exp    int
+	}{
+		{
+			name:   "should write the status",
+			status: http.StatusCreated,
+			exp:    http.StatusCreated,
+		},
+		{
+			name:   "should use status 200 if the status is not 2xx",
+			status: http.StatusNotFound,
+			exp:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.Success(rec, req, tt.status, map[string]string{"key": "value"}); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			assertResponse(t, rec, tt.exp, "application/json", map[string]interface{}{
+				"status": "success",
+				"data":   map[string]interface{}{"key": "value"},
+			})
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		exp    int
+	}{
+		{
+			name:   "should write the status",
+			status: http.StatusConflict,
+			exp:    http.StatusConflict,
+		},
+		{
+			name:   "should use status 400 if the status is not 4xx",
+			status: http.StatusInternalServerError,
+			exp:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := strudel.Fail(rec, req, tt.status, map[string]string{"key": "value"}); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			assertResponse(t, rec, tt.exp, "application/json", map[string]interface{}{
+				"status": "fail",
+				"data":   map[string]interface{}{"key": "value"},
+			})
+		})
+	}
+}
+
+func TestMiddleware_Success(t *testing.T) {
+	custom := strudel.NewDataEncoder("application/vnd.custom+json", nil, func(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+		w.Header().Set("Content-Type", "application/vnd.custom+json")
+		w.WriteHeader(status)
+		return nil
+	})
+
+	tests := []struct {
+		name        string
+		opts        []strudel.Option
+		accept      string
+		contentType string
+	}{
+		{
+			name:        "should use jsend if the default encoder cannot write data",
+			opts:        []strudel.Option{strudel.WithEncoder(strudel.Problem)},
+			accept:      "application/problem+json",
+			contentType: "application/json",
+		},
+		{
+			name:        "should use the default encoder",
+			opts:        []strudel.Option{strudel.WithEncoder(custom)},
+			contentType: "application/vnd.custom+json",
+		},
+		{
+			name:        "should negotiate the configured data encoders",
+			opts:        []strudel.Option{strudel.WithEncoders(strudel.Text, custom)},
+			accept:      "text/plain, application/vnd.custom+json;q=0.5",
+			contentType: "application/vnd.custom+json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			if err := strudel.New(tt.opts...).Success(rec, req, http.StatusOK, nil); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if act := rec.Header().Get("Content-Type"); act != tt.contentType {
+				t.Errorf("got %s, expected %s", act, tt.contentType)
+			}
+
+			if act, exp := rec.Header().Get("Vary"), "Accept"; act != exp {
+				t.Errorf("got %s, expected %s", act, exp)
+			}
+		})
+	}
+}
+
+func TestRequestTracking_TraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name   string
+		fn     janice.HandlerFunc
+		logTyp string
+	}{
+		{
+			name: "should add trace context to request logs",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return nil
+			},
+			logTyp: "request",
+		},
+		{
+			name: "should add trace context to error logs",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return errors.New("error")
+			},
+			logTyp: "error",
+		},
+		{
+			name: "should add trace context to recovery logs",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				panic("error")
+			},
+			logTyp: "recovery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("traceparent", traceparent)
+
+			var tc strudel.TraceContext
+			h := strudel.RequestTracking(strudel.RequestLogging(strudel.Recovery(strudel.ErrorHandling(func(w http.ResponseWriter, r *http.Request) error {
+				tc, _ = strudel.GetTraceContext(r)
+				return tt.fn(w, r)
+			}))))
+
+			if err := h(rec, req); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.ParentSpanID != "00f067aa0ba902b7" {
+				t.Errorf("got %v, expected inbound trace", tc)
+			}
+
+			if tc.SpanID == "" || tc.SpanID == tc.ParentSpanID {
+				t.Errorf("got %s, expected child span id", tc.SpanID)
+			}
+
+			var found bool
+			dec := json.NewDecoder(buf)
+			for dec.More() {
+				e := map[string]interface{}{}
+				if err := dec.Decode(&e); err != nil {
+					t.Fatalf("got %v, expected nil", err)
+				}
+
+				if e["type"] != tt.logTyp {
+					continue
+				}
+
+				found = true
+				if e["trace_id"] != tc.TraceID || e["span_id"] != tc.SpanID {
+					t.Errorf("got %v, expected trace_id %s and span_id %s", e, tc.TraceID, tc.SpanID)
+				}
+			}
+
+			if !found {
+				t.Errorf("got no %s log entry, expected entry", tt.logTyp)
+			}
+		})
+	}
+}
+
+func assertRequestID(exp string) func(*testing.T, string) {
+	return func(t *testing.T, act string) {
+		if act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func setCaptureStack(v bool) func() {
+	pv := strudel.CaptureStack
+	strudel.CaptureStack = v
+
+	return func() {
+		strudel.CaptureStack = pv
+	}
+}
+
 func setLogger(w io.Writer) func() {
 	pl := strudel.Logger
 
@@ -0,0 +1,23 @@
+// Package muxstrudel provides gorilla/mux route template tracking for strudel middleware
+package muxstrudel
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/stevecallear/strudel"
+)
+
+// Middleware is a gorilla/mux middleware function that sets the matched path template as the request route
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt := mux.CurrentRoute(r); rt != nil {
+			if p, err := rt.GetPathTemplate(); err == nil {
+				strudel.SetRoute(r, p)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+package muxstrudel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stevecallear/janice"
+
+	"github.com/stevecallear/strudel"
+	"github.com/stevecallear/strudel/muxstrudel"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		route  string
+		ok     bool
+	}{
+		{
+			name:   "should set the path template",
+			target: "/orders/abc",
+			route:  "/orders/{id}",
+			ok:     true,
+		},
+		{
+			name:   "should set subrouter path templates",
+			target: "/orders/abc/items/1",
+			route:  "/orders/{id}/items/{item:[0-9]+}",
+			ok:     true,
+		},
+		{
+			name:   "should not set the route if no route matches",
+			target: "/unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.NewRouter()
+			r.Use(muxstrudel.Middleware)
+			r.HandleFunc("/orders/{id}", func(http.ResponseWriter, *http.Request) {})
+			r.PathPrefix("/orders/{id}/items").Subrouter().HandleFunc("/{item:[0-9]+}", func(http.ResponseWriter, *http.Request) {})
+
+			var route string
+			var ok bool
+			h := janice.New(strudel.RequestTracking).Then(func(w http.ResponseWriter, req *http.Request) error {
+				r.ServeHTTP(w, req)
+				route, ok = strudel.GetRoute(req)
+				return nil
+			})
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+
+			if route != tt.route || ok != tt.ok {
+				t.Errorf("got %s, %t, expected %s, %t", route, ok, tt.route, tt.ok)
+			}
+		})
+	}
+}
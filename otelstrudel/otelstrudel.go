@@ -0,0 +1,226 @@
+// Package otelstrudel provides OpenTelemetry tracing middleware for strudel
+package otelstrudel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/stevecallear/janice"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stevecallear/strudel"
+)
+
+type (
+	// Option represents a tracing option
+	Option func(*config)
+
+	config struct {
+		provider    trace.TracerProvider
+		propagators propagation.TextMapPropagator
+	}
+)
+
+const instrumentationName = "github.com/stevecallear/strudel/otelstrudel"
+
+// Attribute keys
+const (
+	RequestIDKey  = attribute.Key("strudel.request.id")
+	ErrorCodeKey  = attribute.Key("strudel.error.code")
+	ErrorFieldKey = "strudel.error.field."
+)
+
+// WithTracerProvider configures the tracer provider
+// The global tracer provider is used by default
+func WithTracerProvider(p trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = p
+	}
+}
+
+// WithPropagators configures the propagators used to extract the parent span context
+// The global propagators are used by default
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = p
+	}
+}
+
+// Tracing returns a middleware function that wraps each request in a server span
+// It should be placed before RequestTracking so that log entries contain the span trace and span ids.
+// Errors handled by ErrorHandling, panics recovered by Recovery, the request id and the route are recorded once the request has been served.
+func Tracing(opts ...Option) func(janice.HandlerFunc) janice.HandlerFunc {
+	c := config{
+		provider:    otel.GetTracerProvider(),
+		propagators: otel.GetTextMapPropagator(),
+	}
+
+	for _, o := range opts {
+		o(&c)
+	}
+
+	tracer := c.provider.Tracer(instrumentationName)
+
+	return func(n janice.HandlerFunc) janice.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			ctx := c.propagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			parent := trace.SpanContextFromContext(ctx)
+
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				))
+
+			r = r.WithContext(ctx)
+
+			// no-op tracers return the parent span context, which must not be used as the current span
+			if sc := span.SpanContext(); sc.IsValid() && sc.SpanID() != parent.SpanID() {
+				r = strudel.SetTraceContext(r, traceContext(sc, parent))
+			}
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					span.RecordError(fmt.Errorf("%v", rec), trace.WithStackTrace(true))
+					span.SetStatus(codes.Error, "panic")
+					span.End()
+
+					panic(rec)
+				}
+
+				span.End()
+			}()
+
+			var err error
+			m := httpsnoop.CaptureMetricsFn(w, func(ww http.ResponseWriter) {
+				err = n(ww, r)
+			})
+
+			code := m.Code
+			if err != nil {
+				code = recordError(span, err)
+			} else if he, ok := strudel.HandledError(r); ok {
+				recordHandledError(span, he)
+			}
+
+			if rid, ok := strudel.GetRequestID(r); ok {
+				span.SetAttributes(RequestIDKey.String(rid))
+			}
+
+			if rt, ok := strudel.GetRoute(r); ok {
+				span.SetName(r.Method + " " + rt)
+				span.SetAttributes(semconv.HTTPRoute(rt))
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+			if code >= 500 && err == nil {
+				span.SetStatus(codes.Error, "")
+			}
+
+			return err
+		}
+	}
+}
+
+// recordError records the error on the span and returns the resulting status code
+func recordError(span trace.Span, err error) int {
+	span.RecordError(err)
+
+	se, ok := strudel.AsError(err)
+	if !ok {
+		span.SetStatus(codes.Error, err.Error())
+		return http.StatusInternalServerError
+	}
+
+	if c := se.Code(); c > 0 {
+		span.SetAttributes(ErrorCodeKey.Int(c))
+	}
+
+	f := se.Fields()
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		span.SetAttributes(attributeValue(ErrorFieldKey+k, f[k]))
+	}
+
+	code := se.StatusCode()
+	if code >= 500 {
+		span.SetStatus(codes.Error, se.Message())
+	}
+
+	return code
+}
+
+// recordHandledError records an error that was written by ErrorHandling or Recovery
+func recordHandledError(span trace.Span, err *strudel.Error) {
+	if !err.Recovered() {
+		recordError(span, err)
+		return
+	}
+
+	cause := errors.Unwrap(err)
+	if cause == nil {
+		cause = err
+	}
+
+	span.RecordError(cause, trace.WithAttributes(semconv.ExceptionStacktrace(formatStack(err.Stack()))))
+	span.SetStatus(codes.Error, "panic")
+}
+
+// traceContext returns the strudel trace context for the span
+func traceContext(sc, parent trace.SpanContext) strudel.TraceContext {
+	tc := strudel.TraceContext{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Flags:   byte(sc.TraceFlags()),
+		State:   sc.TraceState().String(),
+	}
+
+	if parent.IsValid() {
+		tc.ParentSpanID = parent.SpanID().String()
+	}
+
+	return tc
+}
+
+func formatStack(st []strudel.Frame) string {
+	var sb strings.Builder
+	for _, f := range st {
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+
+	return sb.String()
+}
+
+func attributeValue(k string, v interface{}) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return attribute.String(k, v)
+	case bool:
+		return attribute.Bool(k, v)
+	case int:
+		return attribute.Int(k, v)
+	case int64:
+		return attribute.Int64(k, v)
+	case float64:
+		return attribute.Float64(k, v)
+	case fmt.Stringer:
+		return attribute.String(k, v.String())
+	default:
+		return attribute.String(k, fmt.Sprint(v))
+	}
+}
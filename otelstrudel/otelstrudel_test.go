@@ -0,0 +1,424 @@
+package otelstrudel_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stevecallear/janice"
+	"github.com/stevecallear/strudel"
+	"github.com/stevecallear/strudel/otelstrudel"
+)
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     janice.HandlerFunc
+		status codes.Code
+		attrs  map[attribute.Key]attribute.Value
+		events []string
+	}{
+		{
+			name: "should record successful requests",
+			fn: func(w http.ResponseWriter, _ *http.Request) error {
+				w.WriteHeader(http.StatusCreated)
+				return nil
+			},
+			status: codes.Unset,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.request.method":       attribute.StringValue("GET"),
+				"url.path":                  attribute.StringValue("/path"),
+				"http.response.status_code": attribute.IntValue(http.StatusCreated),
+			},
+		},
+		{
+			name: "should record 4xx errors as attributes",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return strudel.NewError("error").
+					WithCode(http.StatusNotFound).
+					WithField("resourceId", "abc123").
+					WithLogField("sensitiveId", "def456")
+			},
+			status: codes.Unset,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.response.status_code":       attribute.IntValue(http.StatusNotFound),
+				"strudel.error.code":              attribute.IntValue(http.StatusNotFound),
+				"strudel.error.field.resourceId":  attribute.StringValue("abc123"),
+				"strudel.error.field.sensitiveId": {},
+			},
+			events: []string{"exception"},
+		},
+		{
+			name: "should record 5xx errors as span status",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return strudel.NewError("error").WithCode(http.StatusServiceUnavailable)
+			},
+			status: codes.Error,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.response.status_code": attribute.IntValue(http.StatusServiceUnavailable),
+				"strudel.error.code":        attribute.IntValue(http.StatusServiceUnavailable),
+			},
+			events: []string{"exception"},
+		},
+		{
+			name: "should record other errors as span status",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return errors.New("error")
+			},
+			status: codes.Error,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.response.status_code": attribute.IntValue(http.StatusInternalServerError),
+			},
+			events: []string{"exception"},
+		},
+		{
+			name: "should record the request id",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return nil
+			},
+			status: codes.Unset,
+			attrs: map[attribute.Key]attribute.Value{
+				"strudel.request.id": attribute.StringValue("requestId"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil)
+			req.Header.Set("X-Request-ID", "requestId")
+
+			h := strudel.RequestTracking(otelstrudel.Tracing(otelstrudel.WithTracerProvider(tp))(tt.fn))
+			h(rec, req)
+
+			span := singleSpan(t, exp)
+
+			if span.SpanKind != trace.SpanKindServer {
+				t.Errorf("got %v, expected %v", span.SpanKind, trace.SpanKindServer)
+			}
+
+			if span.Status.Code != tt.status {
+				t.Errorf("got %v, expected %v", span.Status.Code, tt.status)
+			}
+
+			assertAttributes(t, span.Attributes, tt.attrs)
+			assertEvents(t, span.Events, tt.events)
+		})
+	}
+}
+
+func TestTracing_Route(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+		span  string
+		attrs map[attribute.Key]attribute.Value
+	}{
+		{
+			name: "should use the method as the span name",
+			span: "GET",
+			attrs: map[attribute.Key]attribute.Value{
+				"http.route": {},
+			},
+		},
+		{
+			name:  "should record the route",
+			route: "/orders/{id}",
+			span:  "GET /orders/{id}",
+			attrs: map[attribute.Key]attribute.Value{
+				"http.route": attribute.StringValue("/orders/{id}"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/abc", nil)
+
+			h := strudel.RequestTracking(otelstrudel.Tracing(otelstrudel.WithTracerProvider(tp))(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.route != "" {
+					strudel.SetRoute(r, tt.route)
+				}
+				return nil
+			}))
+			h(rec, req)
+
+			span := singleSpan(t, exp)
+
+			if span.Name != tt.span {
+				t.Errorf("got %s, expected %s", span.Name, tt.span)
+			}
+
+			assertAttributes(t, span.Attributes, tt.attrs)
+		})
+	}
+}
+
+func TestTracing_Panic(t *testing.T) {
+	t.Run("should record panics as exception events", func(t *testing.T) {
+		exp := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		func() {
+			defer func() {
+				if rec := recover(); rec != "error" {
+					t.Errorf("got %v, expected error", rec)
+				}
+			}()
+
+			otelstrudel.Tracing(otelstrudel.WithTracerProvider(tp))(func(http.ResponseWriter, *http.Request) error {
+				panic("error")
+			})(rec, req)
+		}()
+
+		span := singleSpan(t, exp)
+
+		if span.Status.Code != codes.Error {
+			t.Errorf("got %v, expected %v", span.Status.Code, codes.Error)
+		}
+
+		assertEvents(t, span.Events, []string{"exception"})
+
+		var stack bool
+		for _, a := range span.Events[0].Attributes {
+			if a.Key == "exception.stacktrace" {
+				stack = true
+			}
+		}
+
+		if !stack {
+			t.Error("got false, expected exception stack trace")
+		}
+	})
+}
+
+func TestTracing_Propagation(t *testing.T) {
+	t.Run("should use the inbound span as the parent", func(t *testing.T) {
+		exp := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		var sc trace.SpanContext
+		otelstrudel.Tracing(
+			otelstrudel.WithTracerProvider(tp),
+			otelstrudel.WithPropagators(propagation.TraceContext{}),
+		)(func(_ http.ResponseWriter, r *http.Request) error {
+			sc = trace.SpanContextFromContext(r.Context())
+			return nil
+		})(rec, req)
+
+		span := singleSpan(t, exp)
+
+		if act, exp := span.Parent.SpanID().String(), "00f067aa0ba902b7"; act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+
+		if act, exp := sc.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+	})
+}
+
+type captureSink struct {
+	fields []strudel.Fields
+}
+
+func (s *captureSink) Log(_ strudel.Level, _ string, fields strudel.Fields) {
+	s.fields = append(s.fields, fields)
+}
+
+func TestTracing_Chain(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		fn          janice.HandlerFunc
+		status      codes.Code
+		attrs       map[attribute.Key]attribute.Value
+		events      []string
+		stack       bool
+		entries     int
+	}{
+		{
+			name:        "should use the span as the log trace context",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			fn: func(w http.ResponseWriter, r *http.Request) error {
+				strudel.SetRoute(r, "/orders/{id}")
+				return nil
+			},
+			status: codes.Unset,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.route":         attribute.StringValue("/orders/{id}"),
+				"strudel.request.id": attribute.StringValue("requestId"),
+			},
+			entries: 1,
+		},
+		{
+			name: "should use the root span as the log trace context",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return nil
+			},
+			status:  codes.Unset,
+			entries: 1,
+		},
+		{
+			name: "should record errors handled by ErrorHandling",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				return strudel.NewError("error").WithCode(http.StatusServiceUnavailable)
+			},
+			status: codes.Error,
+			attrs: map[attribute.Key]attribute.Value{
+				"strudel.error.code":        attribute.IntValue(http.StatusServiceUnavailable),
+				"http.response.status_code": attribute.IntValue(http.StatusServiceUnavailable),
+			},
+			events:  []string{"exception"},
+			entries: 2,
+		},
+		{
+			name: "should record panics recovered by Recovery",
+			fn: func(http.ResponseWriter, *http.Request) error {
+				panic("error")
+			},
+			status: codes.Error,
+			attrs: map[attribute.Key]attribute.Value{
+				"http.response.status_code": attribute.IntValue(http.StatusInternalServerError),
+			},
+			events:  []string{"exception"},
+			stack:   true,
+			entries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+			sink := new(captureSink)
+			m := strudel.New(strudel.WithLogger(sink))
+
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/abc", nil)
+			req.Header.Set("X-Request-ID", "requestId")
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+
+			janice.New(
+				otelstrudel.Tracing(otelstrudel.WithTracerProvider(tp), otelstrudel.WithPropagators(propagation.TraceContext{})),
+				m.RequestTracking,
+				m.Recovery,
+				m.RequestLogging,
+				m.ErrorHandling,
+			).Then(tt.fn).ServeHTTP(rec, req)
+
+			span := singleSpan(t, exp)
+
+			if span.Status.Code != tt.status {
+				t.Errorf("got %v, expected %v", span.Status.Code, tt.status)
+			}
+
+			assertAttributes(t, span.Attributes, tt.attrs)
+			assertEvents(t, span.Events, tt.events)
+
+			if tt.stack {
+				var st string
+				for _, a := range span.Events[0].Attributes {
+					if a.Key == "exception.stacktrace" {
+						st = a.Value.AsString()
+					}
+				}
+
+				if !strings.Contains(st, "TestTracing_Chain") {
+					t.Errorf("got %s, expected panicking function stack trace", st)
+				}
+			}
+
+			if len(sink.fields) != tt.entries {
+				t.Fatalf("got %d entries, expected %d", len(sink.fields), tt.entries)
+			}
+
+			for _, f := range sink.fields {
+				if act, exp := f["trace_id"], span.SpanContext.TraceID().String(); act != exp {
+					t.Errorf("got %v, expected %s", act, exp)
+				}
+
+				if act, exp := f["span_id"], span.SpanContext.SpanID().String(); act != exp {
+					t.Errorf("got %v, expected %s", act, exp)
+				}
+			}
+
+			if tt.traceparent != "" {
+				if act, exp := span.SpanContext.TraceID().String(), tt.traceparent[3:35]; act != exp {
+					t.Errorf("got %s, expected %s", act, exp)
+				}
+			}
+		})
+	}
+}
+
+func singleSpan(t *testing.T, exp *tracetest.InMemoryExporter) tracetest.SpanStub {
+	t.Helper()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, expected 1", len(spans))
+	}
+
+	return spans[0]
+}
+
+// assertAttributes asserts the expected attributes, where an empty value asserts that the key is not set
+func assertAttributes(t *testing.T, act []attribute.KeyValue, exp map[attribute.Key]attribute.Value) {
+	t.Helper()
+
+	m := map[attribute.Key]attribute.Value{}
+	for _, a := range act {
+		m[a.Key] = a.Value
+	}
+
+	for k, v := range exp {
+		av, ok := m[k]
+		if v.Type() == attribute.INVALID {
+			if ok {
+				t.Errorf("got %s:%v, expected no attribute", k, av.Emit())
+			}
+			continue
+		}
+
+		if av != v {
+			t.Errorf("got %s:%v, expected %s:%v", k, av.Emit(), k, v.Emit())
+		}
+	}
+}
+
+func assertEvents(t *testing.T, act []sdktrace.Event, exp []string) {
+	t.Helper()
+
+	if len(act) != len(exp) {
+		t.Fatalf("got %d events, expected %d", len(act), len(exp))
+	}
+
+	for i, e := range act {
+		if e.Name != exp[i] {
+			t.Errorf("got %s, expected %s", e.Name, exp[i])
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package strudel
+
+import (
+	"net/url"
+	"strings"
+)
+
+var (
+	// ScrubQueryParams are the query parameter names that are masked in request logs
+	// Names are case insensitive and can contain * wildcards
+	ScrubQueryParams []string
+
+	// ScrubPathParams are the route template parameter names that are masked in request logs
+	// Names are case insensitive and can contain * wildcards
+	ScrubPathParams []string
+
+	// SplitQuery logs the request path and query as separate fields if it is true
+	SplitQuery = false
+)
+
+// scrubQuery returns the raw query with the values of matching parameters masked
+// Parameter order and encoding are preserved
+func scrubQuery(raw string, names []string) string {
+	if raw == "" || len(names) < 1 {
+		return raw
+	}
+
+	pairs := strings.Split(raw, "&")
+	for i, p := range pairs {
+		k, _, _ := strings.Cut(p, "=")
+		if matchParam(k, names) {
+			pairs[i] = k + "=" + Redacted
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// queryFields returns the query parameters with the values of matching parameters masked
+// Parameters with a single value are returned as strings, otherwise as string slices
+func queryFields(raw string, names []string) map[string]interface{} {
+	vs, _ := url.ParseQuery(raw)
+	if len(vs) < 1 {
+		return nil
+	}
+
+	f := make(map[string]interface{}, len(vs))
+	for k, v := range vs {
+		if matchParam(k, names) {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+
+		if len(v) == 1 {
+			f[k] = v[0]
+		} else {
+			f[k] = v
+		}
+	}
+
+	return f
+}
+
+// scrubPath masks the path segments that match the named route template parameters
+// The route must be the full path template, for example /users/{email}, as set using SetRoute
+func scrubPath(u *url.URL, route string, names []string) {
+	if route == "" || len(names) < 1 {
+		return
+	}
+
+	if !strings.HasPrefix(route, "/") {
+		// remove any host from ServeMux patterns
+		if i := strings.Index(route, "/"); i >= 0 {
+			route = route[i:]
+		}
+	}
+
+	segs := strings.Split(u.EscapedPath(), "/")
+	masked := false
+
+	for i, t := range strings.Split(route, "/") {
+		if i >= len(segs) {
+			break
+		}
+
+		name, rest, ok := routeParam(t)
+		if !ok || !matchParam(name, names) {
+			continue
+		}
+
+		masked = true
+		if rest {
+			segs = append(segs[:i], Redacted)
+			break
+		}
+
+		segs[i] = Redacted
+	}
+
+	if !masked {
+		return
+	}
+
+	raw := strings.Join(segs, "/")
+	if p, err := url.PathUnescape(raw); err == nil {
+		u.Path, u.RawPath = p, raw
+	}
+}
+
+// routeParam returns the parameter name if the route template segment is a parameter
+// Both {name} and {name:pattern} parameters are supported, along with {name...} for the remaining path
+func routeParam(seg string) (string, bool, bool) {
+	if len(seg) < 3 || seg[0] != '{' || seg[len(seg)-1] != '}' {
+		return "", false, false
+	}
+
+	name, _, _ := strings.Cut(seg[1:len(seg)-1], ":")
+	if n := strings.TrimSuffix(name, "..."); n != name {
+		return n, true, true
+	}
+
+	return name, false, true
+}
+
+func matchParam(key string, names []string) bool {
+	if k, err := url.QueryUnescape(key); err == nil {
+		key = k
+	}
+
+	key = strings.ToLower(key)
+	for _, n := range names {
+		if matchGlob(strings.ToLower(n), key) {
+			return true
+		}
+	}
+
+	return false
+}
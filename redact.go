@@ -0,0 +1,256 @@
+package strudel
+
+import (
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+type (
+	// Secret represents a sensitive value that is always logged as [REDACTED]
+	// The underlying value can be accessed by converting the secret to a string
+	Secret string
+
+	// RedactionRule represents a log redaction rule
+	RedactionRule struct {
+		keys  []string
+		rx    *regexp.Regexp
+		valid func(string) bool
+	}
+
+	redactingSink struct {
+		sink   LogSink
+		keys   []string
+		values []RedactionRule
+	}
+)
+
+// Redacted is the value logged in place of redacted values
+const Redacted = "[REDACTED]"
+
+var (
+	// RedactCardNumbers redacts card numbers that pass the Luhn check from log values
+	RedactCardNumbers = RedactionRule{
+		rx:    regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid: luhn,
+	}
+
+	// RedactJWTs redacts JSON web tokens from log values
+	RedactJWTs = RedactPattern(regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`))
+
+	// RedactEmails redacts email addresses from log values
+	RedactEmails = RedactPattern(regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`))
+
+	// DefaultRedactionRules are the rules used by WithRedaction if no rules are specified
+	DefaultRedactionRules = []RedactionRule{
+		RedactKeys("password", "passwd", "secret", "*_secret", "authorization", "cookie", "set-cookie", "*token*", "*api_key*", "*apikey*", "*api-key*"),
+		RedactCardNumbers,
+		RedactJWTs,
+		RedactEmails,
+	}
+
+	// RedactionRules are the rules applied to middleware log entries
+	// Log entries are not redacted if it is empty
+	RedactionRules []RedactionRule
+
+	// correlationFields are the middleware entry fields that are exempt from value rules
+	// Request ids, such as snowflake ids, can otherwise be mistaken for card numbers
+	correlationFields = []string{"request", "trace_id", "span_id"}
+)
+
+// String returns the redacted value
+func (Secret) String() string {
+	return Redacted
+}
+
+// GoString returns the redacted value
+func (Secret) GoString() string {
+	return Redacted
+}
+
+// MarshalJSON returns the redacted value as JSON
+func (Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// LogValue returns the redacted value as a slog value
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// RedactKeys returns a rule that redacts values with keys matching the specified patterns
+// Patterns are case insensitive and can contain * wildcards
+func RedactKeys(patterns ...string) RedactionRule {
+	keys := make([]string, len(patterns))
+	for i, p := range patterns {
+		keys[i] = strings.ToLower(p)
+	}
+
+	return RedactionRule{keys: keys}
+}
+
+// RedactPattern returns a rule that redacts matches of the specified pattern from log values
+func RedactPattern(rx *regexp.Regexp) RedactionRule {
+	return RedactionRule{rx: rx}
+}
+
+// NewRedactingSink returns a new log sink that redacts entries before writing to the specified sink
+// Key rules are applied to field keys, including nested map keys, and value rules to messages and string values
+// Value rules are not applied to the top level request, trace_id and span_id fields so that entries can be correlated
+func NewRedactingSink(s LogSink, rules ...RedactionRule) LogSink {
+	rs := &redactingSink{sink: s}
+	for _, r := range rules {
+		rs.keys = append(rs.keys, r.keys...)
+
+		if r.rx != nil {
+			rs.values = append(rs.values, r)
+		}
+	}
+
+	return rs
+}
+
+// Log writes the specified entry
+func (s *redactingSink) Log(level Level, msg string, fields Fields) {
+	rf := s.redactFields(fields)
+	for _, k := range correlationFields {
+		if v, ok := fields[k].(string); ok && !s.matchKey(k) {
+			rf[k] = v
+		}
+	}
+
+	s.sink.Log(level, s.redactString(msg), rf)
+}
+
+func (s *redactingSink) redactFields(f Fields) Fields {
+	if f == nil {
+		return nil
+	}
+
+	rf := make(Fields, len(f))
+	for k, v := range f {
+		rf[k] = s.redact(k, v)
+	}
+
+	return rf
+}
+
+func (s *redactingSink) redact(key string, v interface{}) interface{} {
+	if s.matchKey(key) {
+		return Redacted
+	}
+
+	switch vv := v.(type) {
+	case Secret:
+		return Redacted
+	case string:
+		return s.redactString(vv)
+	case error:
+		return s.redactString(vv.Error())
+	case Fields:
+		return s.redactFields(vv)
+	case map[string]interface{}:
+		return map[string]interface{}(s.redactFields(Fields(vv)))
+	case map[string]string:
+		m := make(map[string]string, len(vv))
+		for k, sv := range vv {
+			if s.matchKey(k) {
+				m[k] = Redacted
+			} else {
+				m[k] = s.redactString(sv)
+			}
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(vv))
+		for i, av := range vv {
+			a[i] = s.redact("", av)
+		}
+		return a
+	case []string:
+		a := make([]string, len(vv))
+		for i, av := range vv {
+			a[i] = s.redactString(av)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+func (s *redactingSink) redactString(v string) string {
+	for _, r := range s.values {
+		v = r.rx.ReplaceAllStringFunc(v, func(m string) string {
+			if r.valid != nil && !r.valid(m) {
+				return m
+			}
+
+			return Redacted
+		})
+	}
+
+	return v
+}
+
+func (s *redactingSink) matchKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	key = strings.ToLower(key)
+	for _, p := range s.keys {
+		if matchGlob(p, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob returns true if the value matches the pattern, where * matches any sequence
+func matchGlob(pattern, v string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == v
+	}
+
+	if !strings.HasPrefix(v, parts[0]) {
+		return false
+	}
+	v = v[len(parts[0]):]
+
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(v, p)
+		if i < 0 {
+			return false
+		}
+		v = v[i+len(p):]
+	}
+
+	return strings.HasSuffix(v, parts[len(parts)-1])
+}
+
+// luhn returns true if the digits in the value pass the Luhn check
+func luhn(v string) bool {
+	var sum, n int
+	for i := len(v) - 1; i >= 0; i-- {
+		c := v[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		n++
+	}
+
+	return n > 0 && sum%10 == 0
+}
@@ -0,0 +1,130 @@
+package strudel
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator represents a request id generator
+type IDGenerator func() string
+
+const (
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base62Alphabet    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	ksuidEpoch     = 1400000000
+	snowflakeEpoch = 1577836800000 // 2020-01-01T00:00:00Z
+)
+
+var (
+	// UUIDv4 generates random RFC 4122 version 4 uuids
+	UUIDv4 IDGenerator = uuid.NewString
+
+	// UUIDv7 generates time ordered RFC 9562 version 7 uuids
+	UUIDv7 IDGenerator = func() string {
+		return uuid.Must(uuid.NewV7()).String()
+	}
+
+	// ULID generates time ordered universally unique lexicographically sortable identifiers
+	// Identifiers generated within the same millisecond are not guaranteed to be ordered
+	ULID IDGenerator = func() string {
+		var b [16]byte
+		binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+		randomBytes(b[6:])
+
+		hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+
+		var dst [26]byte
+		for i := len(dst) - 1; i >= 0; i-- {
+			dst[i] = crockfordAlphabet[lo&31]
+			lo = lo>>5 | hi<<59
+			hi >>= 5
+		}
+
+		return string(dst[:])
+	}
+
+	// KSUID generates time ordered k-sortable unique identifiers with second precision
+	KSUID IDGenerator = func() string {
+		var b [20]byte
+		binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+		randomBytes(b[4:])
+
+		return encodeBase62(b[:], 27)
+	}
+)
+
+// NewSnowflake returns a generator for snowflake identifiers with the specified node id
+// Identifiers contain a 41 bit millisecond timestamp, 10 bit node id and 12 bit sequence
+// Only the lower 10 bits of the node id are used
+func NewSnowflake(node int64) IDGenerator {
+	var (
+		mu   sync.Mutex
+		last int64
+		seq  int64
+	)
+
+	node &= 1<<10 - 1
+
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now().UnixMilli() - snowflakeEpoch
+		if now < last {
+			now = last
+		}
+
+		if now == last {
+			seq = (seq + 1) & (1<<12 - 1)
+			if seq == 0 {
+				for now <= last {
+					now = time.Now().UnixMilli() - snowflakeEpoch
+				}
+			}
+		} else {
+			seq = 0
+		}
+
+		last = now
+
+		return strconv.FormatInt(now<<22|node<<12|seq, 10)
+	}
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// encodeBase62 encodes the specified big-endian number, left padding to the specified length
+func encodeBase62(b []byte, n int) string {
+	src := append([]byte(nil), b...)
+	dst := make([]byte, n)
+	for i := range dst {
+		dst[i] = base62Alphabet[0]
+	}
+
+	for i := n - 1; i >= 0 && len(src) > 0; i-- {
+		var rem int
+		quo := src[:0]
+		for _, c := range src {
+			acc := rem<<8 | int(c)
+			if q := acc / 62; q > 0 || len(quo) > 0 {
+				quo = append(quo, byte(q))
+			}
+			rem = acc % 62
+		}
+
+		dst[i] = base62Alphabet[rem]
+		src = quo
+	}
+
+	return string(dst)
+}
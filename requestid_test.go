@@ -0,0 +1,136 @@
+package strudel_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestIDGenerator(t *testing.T) {
+	tests := []struct {
+		name string
+		gen  strudel.IDGenerator
+		exp  *regexp.Regexp
+	}{
+		{
+			name: "UUIDv4",
+			gen:  strudel.UUIDv4,
+			exp:  regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"),
+		},
+		{
+			name: "UUIDv7",
+			gen:  strudel.UUIDv7,
+			exp:  regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"),
+		},
+		{
+			name: "ULID",
+			gen:  strudel.ULID,
+			exp:  regexp.MustCompile("^[0-7][0-9A-HJKMNP-TV-Z]{25}$"),
+		},
+		{
+			name: "KSUID",
+			gen:  strudel.KSUID,
+			exp:  regexp.MustCompile("^[0-9A-Za-z]{27}$"),
+		},
+		{
+			name: "Snowflake",
+			gen:  strudel.NewSnowflake(1),
+			exp:  regexp.MustCompile("^[0-9]{1,19}$"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[string]bool{}
+			for i := 0; i < 1000; i++ {
+				id := tt.gen()
+
+				if !tt.exp.MatchString(id) {
+					t.Fatalf("got %s, expected match for %s", id, tt.exp)
+				}
+
+				if seen[id] {
+					t.Fatalf("got duplicate id %s", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestIDGenerator_Ordering(t *testing.T) {
+	tests := []struct {
+		name string
+		gen  strudel.IDGenerator
+	}{
+		{name: "UUIDv7", gen: strudel.UUIDv7},
+		{name: "ULID", gen: strudel.ULID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.gen()
+			time.Sleep(2 * time.Millisecond)
+			b := tt.gen()
+
+			if a >= b {
+				t.Errorf("got %s >= %s, expected time ordering", a, b)
+			}
+		})
+	}
+}
+
+func TestNewSnowflake(t *testing.T) {
+	t.Run("should generate increasing ids", func(t *testing.T) {
+		gen := strudel.NewSnowflake(1)
+
+		var prev int64
+		for i := 0; i < 10000; i++ {
+			id, err := strconv.ParseInt(gen(), 10, 64)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			if id <= prev {
+				t.Fatalf("got %d <= %d, expected increasing ids", id, prev)
+			}
+			prev = id
+		}
+	})
+
+	t.Run("should include the node id", func(t *testing.T) {
+		id, err := strconv.ParseInt(strudel.NewSnowflake(1023+1024)(), 10, 64)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		if act := id >> 12 & 1023; act != 1023 {
+			t.Errorf("got %d, expected 1023", act)
+		}
+	})
+}
+
+func BenchmarkIDGenerator(b *testing.B) {
+	gens := []struct {
+		name string
+		gen  strudel.IDGenerator
+	}{
+		{name: "UUIDv4", gen: strudel.UUIDv4},
+		{name: "UUIDv7", gen: strudel.UUIDv7},
+		{name: "ULID", gen: strudel.ULID},
+		{name: "KSUID", gen: strudel.KSUID},
+		{name: "Snowflake", gen: strudel.NewSnowflake(1)},
+	}
+
+	for _, g := range gens {
+		b.Run(g.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				g.gen()
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package strudel
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GetRoute returns the matched route template for the specified request
+var GetRoute = func(r *http.Request) (string, bool) {
+	h, ok := getHolder(r)
+	if !ok {
+		return "", false
+	}
+
+	v, _ := h.route.Load().(string)
+	return v, v != ""
+}
+
+// SetRoute sets the matched route template, such as /orders/{id}, for the specified request
+// The route is visible to the outer middleware that tracks the request, typically RequestTracking or RequestLogging
+// False is returned if the request is not tracked
+func SetRoute(r *http.Request, route string) bool {
+	h, ok := getHolder(r)
+	if !ok {
+		return false
+	}
+
+	h.route.Store(route)
+	return true
+}
+
+// RouteServeMux returns a handler that sets the matched ServeMux pattern as the request route
+// Any method prefix, as supported by Go 1.22+ patterns, is removed from the route
+func RouteServeMux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, p := mux.Handler(r); p != "" {
+			SetRoute(r, trimPatternMethod(p))
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func trimPatternMethod(p string) string {
+	if i := strings.IndexAny(p, " \t"); i >= 0 {
+		return strings.TrimLeft(p[i:], " \t")
+	}
+
+	return p
+}
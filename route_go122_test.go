@@ -0,0 +1,35 @@
+//go:build go1.22
+
+//go:debug httpmuxgo121=0
+
+package strudel_test
+
+import "testing"
+
+func TestRouteServeMux_Wildcards(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		route   string
+	}{
+		{
+			name:    "should set the wildcard pattern",
+			pattern: "/orders/{id}",
+			target:  "/orders/abc",
+			route:   "/orders/{id}",
+		},
+		{
+			name:    "should remove the method from the pattern",
+			pattern: "GET /orders/{id}/items/{item...}",
+			target:  "/orders/abc/items/1/2",
+			route:   "/orders/{id}/items/{item...}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertServeMuxRoute(t, tt.pattern, tt.target, tt.route)
+		})
+	}
+}
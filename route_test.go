@@ -0,0 +1,151 @@
+package strudel_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stevecallear/janice"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestSetRoute(t *testing.T) {
+	t.Run("should return false if the request is not tracked", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+
+		if strudel.SetRoute(req, "/orders/{id}") {
+			t.Error("got true, expected false")
+		}
+
+		if act, ok := strudel.GetRoute(req); act != "" || ok {
+			t.Errorf("got %s, %t, expected empty route", act, ok)
+		}
+	})
+
+	t.Run("should set the route for outer middleware", func(t *testing.T) {
+		var route string
+		var ok bool
+
+		h := strudel.RequestTracking(func(w http.ResponseWriter, r *http.Request) error {
+			inner := r.WithContext(r.Context())
+			if !strudel.SetRoute(inner, "/orders/{id}") {
+				t.Error("got false, expected true")
+			}
+
+			route, ok = strudel.GetRoute(r)
+			return nil
+		})
+
+		if err := h(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/abc", nil)); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if route != "/orders/{id}" || !ok {
+			t.Errorf("got %s, %t, expected /orders/{id}, true", route, ok)
+		}
+	})
+}
+
+func TestRouteServeMux(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		target  string
+		route   string
+	}{
+		{
+			name:    "should set the matched pattern",
+			pattern: "/orders/",
+			target:  "/orders/abc",
+			route:   "/orders/",
+		},
+		{
+			name:    "should set the matched host pattern",
+			pattern: "example.com/orders",
+			target:  "/orders",
+			route:   "example.com/orders",
+		},
+		{
+			name:    "should not set the route if no pattern matches",
+			pattern: "/orders",
+			target:  "/unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertServeMuxRoute(t, tt.pattern, tt.target, tt.route)
+		})
+	}
+}
+
+func TestRequestLogging_Route(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+	}{
+		{
+			name: "should not log the route if it is not set",
+		},
+		{
+			name:  "should log the route",
+			route: "/orders/{id}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			restoreLogger := setLogger(buf)
+			defer restoreLogger()
+
+			err := strudel.RequestLogging(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.route != "" {
+					strudel.SetRoute(r, tt.route)
+				}
+				return nil
+			})(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/abc", nil))
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			act := map[string]interface{}{}
+			if err = json.Unmarshal(buf.Bytes(), &act); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			if act["path"] != "/orders/abc" {
+				t.Errorf("got %v, expected /orders/abc", act["path"])
+			}
+
+			if rt, ok := act["route"]; tt.route == "" && ok || tt.route != "" && rt != tt.route {
+				t.Errorf("got %v, expected %s", rt, tt.route)
+			}
+		})
+	}
+}
+
+func assertServeMuxRoute(t *testing.T, pattern, target, route string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+
+	var act string
+	var ok bool
+	h := janice.New(strudel.RequestTracking).Then(func(w http.ResponseWriter, r *http.Request) error {
+		strudel.RouteServeMux(mux).ServeHTTP(w, r)
+		act, ok = strudel.GetRoute(r)
+		return nil
+	})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+
+	if act != route || ok != (route != "") {
+		t.Errorf("got %s, %t, expected %s", act, ok, route)
+	}
+}
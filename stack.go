@@ -0,0 +1,76 @@
+package strudel
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Frame represents a call stack frame
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+const maxStackDepth = 32
+
+// callers returns the trimmed call stack, skipping the specified number of frames
+func callers(skip int) []Frame {
+	pc := make([]uintptr, maxStackDepth)
+	return trimFrames(runtimeFrames(pc[:runtime.Callers(skip+1, pc)]))
+}
+
+// panicStack returns the trimmed call stack of the panicking function
+// It must be called from the deferred function that recovers the panic
+func panicStack() []Frame {
+	pc := make([]uintptr, 2*maxStackDepth)
+
+	fs := runtimeFrames(pc[:runtime.Callers(2, pc)])
+	for i, f := range fs {
+		if f.Function == "runtime.gopanic" {
+			fs = fs[i+1:]
+			break
+		}
+	}
+
+	return trimFrames(fs)
+}
+
+func runtimeFrames(pc []uintptr) []runtime.Frame {
+	if len(pc) < 1 {
+		return nil
+	}
+
+	var fs []runtime.Frame
+	frames := runtime.CallersFrames(pc)
+	for {
+		f, more := frames.Next()
+		fs = append(fs, f)
+
+		if !more {
+			return fs
+		}
+	}
+}
+
+// trimFrames removes runtime frames and limits the stack depth
+func trimFrames(fs []runtime.Frame) []Frame {
+	var st []Frame
+	for _, f := range fs {
+		if strings.HasPrefix(f.Function, "runtime.") {
+			continue
+		}
+
+		st = append(st, Frame{
+			Function: f.Function,
+			File:     f.File,
+			Line:     f.Line,
+		})
+
+		if len(st) >= maxStackDepth {
+			break
+		}
+	}
+
+	return st
+}
@@ -0,0 +1,108 @@
+package strudel
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceContext represents W3C trace context for a request
+type TraceContext struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Flags        byte
+	State        string
+}
+
+var (
+	// GetTraceContext returns the trace context for the specified request
+	GetTraceContext = func(r *http.Request) (TraceContext, bool) {
+		v, ok := r.Context().Value(traceKey).(TraceContext)
+		return v, ok
+	}
+
+	traceKey = contextKey("tracecontext")
+)
+
+// SetTraceContext returns a shallow copy of the request with the specified trace context
+// RequestTracking uses it in place of the traceparent header, allowing tracing middleware to align log entries with its spans.
+// The request is also prepared so that the request id, route and handled error are visible once the request has been served.
+func SetTraceContext(r *http.Request, tc TraceContext) *http.Request {
+	return r.WithContext(holderContext(context.WithValue(r.Context(), traceKey, tc)))
+}
+
+// ParseTraceparent parses the specified W3C traceparent header value
+// The span id of the returned trace context is the parent id from the header
+func ParseTraceparent(v string) (TraceContext, bool) {
+	v = strings.TrimSpace(v)
+	if len(v) < 55 || (len(v) > 55 && v[55] != '-') {
+		return TraceContext{}, false
+	}
+
+	ver, tid, sid, flags := v[0:2], v[3:35], v[36:52], v[53:55]
+	if v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return TraceContext{}, false
+	}
+
+	if !isHex(ver) || ver == "ff" || (ver == "00" && len(v) != 55) {
+		return TraceContext{}, false
+	}
+
+	if !isHex(tid) || isZero(tid) || !isHex(sid) || isZero(sid) || !isHex(flags) {
+		return TraceContext{}, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+
+	return TraceContext{
+		TraceID: tid,
+		SpanID:  sid,
+		Flags:   b[0],
+	}, true
+}
+
+// Sampled returns true if the sampled flag is set
+func (c TraceContext) Sampled() bool {
+	return c.Flags&1 == 1
+}
+
+// Traceparent returns the W3C traceparent header value for the current span
+func (c TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", c.TraceID, c.SpanID, c.Flags)
+}
+
+// childTraceContext returns the trace context for the current hop if the request contains a valid traceparent
+func childTraceContext(r *http.Request) (TraceContext, bool) {
+	p, ok := ParseTraceparent(r.Header.Get("traceparent"))
+	if !ok {
+		return TraceContext{}, false
+	}
+
+	var b [8]byte
+	randomBytes(b[:])
+
+	return TraceContext{
+		TraceID:      p.TraceID,
+		SpanID:       hex.EncodeToString(b[:]),
+		ParentSpanID: p.SpanID,
+		Flags:        p.Flags,
+		State:        strings.Join(r.Header.Values("tracestate"), ","),
+	}, true
+}
+
+func isHex(v string) bool {
+	for _, c := range v {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isZero(v string) bool {
+	return strings.Trim(v, "0") == ""
+}
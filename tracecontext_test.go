@@ -0,0 +1,196 @@
+package strudel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		exp   strudel.TraceContext
+		ok    bool
+	}{
+		{
+			name:  "should parse valid values",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			exp: strudel.TraceContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+				Flags:   1,
+			},
+			ok: true,
+		},
+		{
+			name:  "should parse future versions",
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			exp: strudel.TraceContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+			},
+			ok: true,
+		},
+		{
+			name: "should reject empty values",
+		},
+		{
+			name:  "should reject invalid versions",
+			value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "should reject version 00 with extra data",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		{
+			name:  "should reject uppercase hex",
+			value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "should reject zero trace ids",
+			value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:  "should reject zero span ids",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		{
+			name:  "should reject invalid delimiters",
+			value: "00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+		},
+		{
+			name:  "should reject invalid flags",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, ok := strudel.ParseTraceparent(tt.value)
+
+			if ok != tt.ok {
+				t.Errorf("got %v, expected %v", ok, tt.ok)
+			}
+
+			if act != tt.exp {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestTraceContext_Traceparent(t *testing.T) {
+	t.Run("should format the current span", func(t *testing.T) {
+		const exp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+		act := strudel.TraceContext{
+			TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:       "00f067aa0ba902b7",
+			ParentSpanID: "b7ad6b7169203331",
+			Flags:        1,
+		}.Traceparent()
+
+		if act != exp {
+			t.Errorf("got %s, expected %s", act, exp)
+		}
+	})
+}
+
+func TestGetTraceContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  []string
+		ok          bool
+	}{
+		{
+			name: "should not set trace context if traceparent is not set",
+		},
+		{
+			name:        "should not set trace context if traceparent is invalid",
+			traceparent: "invalid",
+		},
+		{
+			name:        "should set trace context",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			tracestate:  []string{"a=1", "b=2"},
+			ok:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			for _, v := range tt.tracestate {
+				req.Header.Add("tracestate", v)
+			}
+
+			var (
+				act strudel.TraceContext
+				ok  bool
+			)
+			err := strudel.RequestTracking(func(w http.ResponseWriter, r *http.Request) error {
+				act, ok = strudel.GetTraceContext(r)
+				return nil
+			})(rec, req)
+			if err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if ok != tt.ok {
+				t.Fatalf("got %v, expected %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if act.State != "a=1,b=2" {
+				t.Errorf("got %s, expected a=1,b=2", act.State)
+			}
+
+			if !act.Sampled() {
+				t.Error("got false, expected true")
+			}
+
+			if _, ok := strudel.ParseTraceparent(act.Traceparent()); !ok {
+				t.Errorf("got %s, expected valid traceparent", act.Traceparent())
+			}
+		})
+	}
+}
+
+func TestSetTraceContext(t *testing.T) {
+	t.Run("should use the trace context in place of the traceparent header", func(t *testing.T) {
+		exp := strudel.TraceContext{TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Flags: 1}
+
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Set("X-Request-ID", "requestId")
+
+		req = strudel.SetTraceContext(req, exp)
+
+		var act strudel.TraceContext
+		err := strudel.RequestTracking(func(w http.ResponseWriter, r *http.Request) error {
+			act, _ = strudel.GetTraceContext(r)
+			return nil
+		})(rec, req)
+		if err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if act != exp {
+			t.Errorf("got %v, expected %v", act, exp)
+		}
+
+		if act, ok := strudel.GetRequestID(req); !ok || act != "requestId" {
+			t.Errorf("got %s, expected requestId", act)
+		}
+	})
+}
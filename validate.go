@@ -0,0 +1,343 @@
+package strudel
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type rule struct {
+	name  string
+	param string
+}
+
+// ValidationTag is the struct tag used to specify validation rules
+const ValidationTag = "validate"
+
+var (
+	uuidRx = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+	regexCache sync.Map
+)
+
+// Validate validates the specified struct against its validate tags
+// A validation error with a violation for each invalid field is returned if validation fails
+//
+// The following comma separated rules are supported:
+//   - required: the value must not be empty
+//   - min=n, max=n: the value, or length for strings, slices and maps, must be within the bound
+//   - len=n: the length must equal n
+//   - oneof=a b c: the value must be one of the space separated options
+//   - email: the value must be an email address
+//   - uuid: the value must be a uuid
+//   - regex=pattern: the value must match the pattern, which must be the last rule as it can contain commas
+//
+// Rules other than required are not applied to empty strings, slices, maps or nil pointers.
+// Nested structs, and slices of structs, are validated using JSON field paths.
+// Embedded structs without a JSON name are flattened, and interface values are validated using the dynamic value.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("strudel: cannot validate %T, expected struct", v)
+	}
+
+	var vs []Violation
+	if err := validateStruct(rv, "", &vs); err != nil {
+		return err
+	}
+
+	if len(vs) < 1 {
+		return nil
+	}
+
+	return newError("validation failed", nil).
+		WithCode(http.StatusUnprocessableEntity).
+		WithViolations(vs...)
+}
+
+func validateStruct(rv reflect.Value, prefix string, vs *[]Violation) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" && !isEmbeddedStruct(sf) {
+			continue
+		}
+
+		path := prefix
+		if !isEmbeddedStruct(sf) || hasJSONName(sf) {
+			path = joinPath(prefix, fieldName(sf))
+		}
+
+		fv := rv.Field(i)
+
+		if tag, ok := sf.Tag.Lookup(ValidationTag); ok {
+			for _, r := range parseRules(tag) {
+				v, err := validateRule(fv, path, r)
+				if err != nil {
+					return fmt.Errorf("strudel: invalid validation rule %s for %s: %w", r.name, path, err)
+				}
+
+				if v != nil {
+					*vs = append(*vs, *v)
+					break
+				}
+			}
+		}
+
+		if err := validateNested(fv, path, vs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateNested(fv reflect.Value, path string, vs *[]Violation) error {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		return validateStruct(fv, path, vs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			if err := validateNested(fv.Index(i), path+"["+strconv.Itoa(i)+"]", vs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRule(fv reflect.Value, path string, r rule) (*Violation, error) {
+	for fv.Kind() == reflect.Interface && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+
+	if r.name == "required" {
+		if isEmpty(fv) {
+			return &Violation{Path: path, Code: r.name, Message: path + " is required"}, nil
+		}
+		return nil, nil
+	}
+
+	if isEmpty(fv) && fv.Kind() != reflect.Bool && !isNumber(fv) {
+		return nil, nil
+	}
+
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+
+	var (
+		ok  bool
+		msg string
+		err error
+	)
+
+	switch r.name {
+	case "min", "max", "len":
+		ok, msg, err = validateBound(fv, path, r)
+	case "oneof":
+		opts := strings.Fields(r.param)
+		ok = contains(opts, fmt.Sprint(fv.Interface()))
+		msg = fmt.Sprintf("%s must be one of %s", path, strings.Join(opts, ", "))
+	case "email":
+		s, serr := stringValue(fv)
+		if serr != nil {
+			return nil, serr
+		}
+		a, perr := mail.ParseAddress(s)
+		ok = perr == nil && a.Address == s
+		msg = path + " must be a valid email address"
+	case "uuid":
+		s, serr := stringValue(fv)
+		if serr != nil {
+			return nil, serr
+		}
+		ok = uuidRx.MatchString(s)
+		msg = path + " must be a valid uuid"
+	case "regex":
+		s, serr := stringValue(fv)
+		if serr != nil {
+			return nil, serr
+		}
+		rx, rerr := compileRegex(r.param)
+		if rerr != nil {
+			return nil, rerr
+		}
+		ok = rx.MatchString(s)
+		msg = fmt.Sprintf("%s must match %s", path, r.param)
+	default:
+		return nil, fmt.Errorf("unknown rule")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return nil, nil
+	}
+
+	return &Violation{Path: path, Code: r.name, Message: msg}, nil
+}
+
+func validateBound(fv reflect.Value, path string, r rule) (bool, string, error) {
+	n, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		return false, "", err
+	}
+
+	var (
+		v    float64
+		unit string
+	)
+
+	switch fv.Kind() {
+	case reflect.String:
+		v, unit = float64(len([]rune(fv.String()))), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		v, unit = float64(fv.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = fv.Float()
+	default:
+		return false, "", fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	switch r.name {
+	case "min":
+		return v >= n, fmt.Sprintf("%s must be at least %s%s", path, r.param, unit), nil
+	case "max":
+		return v <= n, fmt.Sprintf("%s must be at most %s%s", path, r.param, unit), nil
+	default:
+		if unit == "" {
+			return false, "", fmt.Errorf("unsupported type %s", fv.Type())
+		}
+		return v == n, fmt.Sprintf("%s must be exactly %s%s", path, r.param, unit), nil
+	}
+}
+
+func parseRules(tag string) []rule {
+	var rules []rule
+	for tag != "" {
+		var s string
+		if strings.HasPrefix(tag, "regex=") {
+			s, tag = tag, ""
+		} else {
+			s, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(s), "=")
+		if name == "" {
+			continue
+		}
+
+		rules = append(rules, rule{name: name, param: param})
+	}
+
+	return rules
+}
+
+func fieldName(sf reflect.StructField) string {
+	if hasJSONName(sf) {
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		return name
+	}
+
+	return sf.Name
+}
+
+func hasJSONName(sf reflect.StructField) bool {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	return name != "" && name != "-"
+}
+
+// isEmbeddedStruct returns true if the field is an embedded struct, which encoding/json flattens into the parent
+func isEmbeddedStruct(sf reflect.StructField) bool {
+	if !sf.Anonymous {
+		return false
+	}
+
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return fv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	default:
+		return fv.IsZero()
+	}
+}
+
+func isNumber(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func stringValue(fv reflect.Value) (string, error) {
+	if fv.Kind() != reflect.String {
+		return "", fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return fv.String(), nil
+}
+
+func compileRegex(p string) (*regexp.Regexp, error) {
+	if rx, ok := regexCache.Load(p); ok {
+		return rx.(*regexp.Regexp), nil
+	}
+
+	rx, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.Store(p, rx)
+	return rx, nil
+}
+
+func contains(s []string, v string) bool {
+	for _, sv := range s {
+		if sv == v {
+			return true
+		}
+	}
+
+	return false
+}
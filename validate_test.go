@@ -0,0 +1,225 @@
+package strudel_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+type (
+	validateAddress struct {
+		Street   string `json:"street" validate:"required"`
+		Postcode string `json:"postcode" validate:"regex=^[A-Z]{2}[0-9]{1,2}$"`
+	}
+
+	validateItem struct {
+		SKU      string `json:"sku" validate:"required,len=8"`
+		Quantity int    `json:"quantity" validate:"min=1,max=10"`
+	}
+
+	validateAudit struct {
+		CreatedBy string `json:"createdBy" validate:"required"`
+	}
+
+	validateEmbedded struct {
+		validateAudit
+		Meta interface{} `json:"meta" validate:"max=3"`
+	}
+
+	validateOrder struct {
+		ID       string           `json:"id" validate:"uuid"`
+		Email    string           `json:"email" validate:"required,email"`
+		Status   string           `json:"status" validate:"oneof=pending paid"`
+		Note     *string          `json:"note,omitempty" validate:"max=5"`
+		Tags     []string         `json:"tags" validate:"max=2"`
+		Address  *validateAddress `json:"address" validate:"required"`
+		Items    []validateItem   `json:"items" validate:"required,min=1"`
+		Internal string           `json:"-" validate:"required"`
+		hidden   string           `validate:"required"`
+	}
+)
+
+func TestValidate(t *testing.T) {
+	note := "too long"
+
+	valid := func() validateOrder {
+		return validateOrder{
+			ID:       "7d444840-9dc0-11d1-b245-5ffdce74fad2",
+			Email:    "user@example.com",
+			Status:   "paid",
+			Address:  &validateAddress{Street: "street", Postcode: "AB12"},
+			Items:    []validateItem{{SKU: "ABCD1234", Quantity: 1}},
+			Internal: "value",
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input interface{}
+		exp   []strudel.Violation
+	}{
+		{
+			name:  "should return nil for valid structs",
+			input: func() interface{} { v := valid(); return &v }(),
+		},
+		{
+			name:  "should accept struct values",
+			input: valid(),
+		},
+		{
+			name: "should validate required fields",
+			input: func() interface{} {
+				v := valid()
+				v.Email, v.Address, v.Internal = "", nil, ""
+				return &v
+			}(),
+			exp: []strudel.Violation{
+				{Path: "email", Code: "required", Message: "email is required"},
+				{Path: "address", Code: "required", Message: "address is required"},
+				{Path: "Internal", Code: "required", Message: "Internal is required"},
+			},
+		},
+		{
+			name: "should validate formats",
+			input: func() interface{} {
+				v := valid()
+				v.ID, v.Email, v.Status = "invalid", "invalid", "unknown"
+				return &v
+			}(),
+			exp: []strudel.Violation{
+				{Path: "id", Code: "uuid", Message: "id must be a valid uuid"},
+				{Path: "email", Code: "email", Message: "email must be a valid email address"},
+				{Path: "status", Code: "oneof", Message: "status must be one of pending, paid"},
+			},
+		},
+		{
+			name: "should validate lengths",
+			input: func() interface{} {
+				v := valid()
+				v.Note, v.Tags, v.Items = &note, []string{"a", "b", "c"}, []validateItem{}
+				return &v
+			}(),
+			exp: []strudel.Violation{
+				{Path: "note", Code: "max", Message: "note must be at most 5 characters"},
+				{Path: "tags", Code: "max", Message: "tags must be at most 2 items"},
+				{Path: "items", Code: "required", Message: "items is required"},
+			},
+		},
+		{
+			name: "should validate nested structs",
+			input: func() interface{} {
+				v := valid()
+				v.Address = &validateAddress{Postcode: "invalid"}
+				v.Items = []validateItem{{SKU: "ABCD1234", Quantity: 1}, {SKU: "ABC", Quantity: 11}}
+				return &v
+			}(),
+			exp: []strudel.Violation{
+				{Path: "address.street", Code: "required", Message: "address.street is required"},
+				{Path: "address.postcode", Code: "regex", Message: "address.postcode must match ^[A-Z]{2}[0-9]{1,2}$"},
+				{Path: "items[1].sku", Code: "len", Message: "items[1].sku must be exactly 8 characters"},
+				{Path: "items[1].quantity", Code: "max", Message: "items[1].quantity must be at most 10"},
+			},
+		},
+		{
+			name:  "should flatten embedded structs",
+			input: &validateEmbedded{},
+			exp: []strudel.Violation{
+				{Path: "createdBy", Code: "required", Message: "createdBy is required"},
+			},
+		},
+		{
+			name: "should validate interface values",
+			input: &validateEmbedded{
+				validateAudit: validateAudit{CreatedBy: "user"},
+				Meta:          "abcd",
+			},
+			exp: []strudel.Violation{
+				{Path: "meta", Code: "max", Message: "meta must be at most 3 characters"},
+			},
+		},
+		{
+			name: "should not validate empty interface values",
+			input: &validateEmbedded{
+				validateAudit: validateAudit{CreatedBy: "user"},
+				Meta:          "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strudel.Validate(tt.input)
+
+			if tt.exp == nil {
+				if err != nil {
+					t.Errorf("got %v, expected nil", err)
+				}
+				return
+			}
+
+			se, ok := err.(*strudel.Error)
+			if !ok {
+				t.Fatalf("got %T, expected *strudel.Error", err)
+			}
+
+			if act, exp := se.Code(), http.StatusUnprocessableEntity; act != exp {
+				t.Errorf("got %d, expected %d", act, exp)
+			}
+
+			if act := se.Fields()["errors"]; !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{
+			name:  "should return an error for non struct values",
+			input: "value",
+		},
+		{
+			name: "should return an error for unknown rules",
+			input: struct {
+				Value string `validate:"unknown"`
+			}{Value: "value"},
+		},
+		{
+			name: "should return an error for invalid parameters",
+			input: struct {
+				Value string `validate:"min=a"`
+			}{Value: "value"},
+		},
+		{
+			name: "should return an error for invalid patterns",
+			input: struct {
+				Value string `validate:"regex=["`
+			}{Value: "value"},
+		},
+		{
+			name: "should return an error for unsupported types",
+			input: struct {
+				Value int `validate:"email"`
+			}{Value: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strudel.Validate(tt.input)
+			if err == nil {
+				t.Fatal("got nil, expected error")
+			}
+
+			if _, ok := err.(*strudel.Error); ok {
+				t.Errorf("got %v, expected non validation error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package strudel
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Violation represents a validation violation for a single field
+type Violation struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ViolationsField is the error field used to write validation violations
+const ViolationsField = "errors"
+
+// NewValidationError returns a new unprocessable entity error for validation violations
+func NewValidationError(msg string) *Error {
+	return newError(msg, nil).WithCode(http.StatusUnprocessableEntity)
+}
+
+// WithViolation adds a validation violation for the specified JSON path
+func (e *Error) WithViolation(path, code, msg string) *Error {
+	return e.WithViolations(Violation{
+		Path:    path,
+		Code:    code,
+		Message: msg,
+	})
+}
+
+// WithViolations adds the specified validation violations
+func (e *Error) WithViolations(v ...Violation) *Error {
+	if len(v) < 1 {
+		return e
+	}
+
+	e.violations = append(e.violations, v...)
+	e.fields[ViolationsField] = e.violations
+
+	return e
+}
+
+// MergeViolations adds the violations from the specified error, prefixing each path
+// It can be used to merge violations from nested structs
+func (e *Error) MergeViolations(prefix string, err *Error) *Error {
+	if err == nil {
+		return e
+	}
+
+	v := make([]Violation, len(err.violations))
+	for i, vv := range err.violations {
+		vv.Path = joinPath(prefix, vv.Path)
+		v[i] = vv
+	}
+
+	return e.WithViolations(v...)
+}
+
+// Violations returns the validation violations
+func (e *Error) Violations() []Violation {
+	return e.violations
+}
+
+func joinPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	case strings.HasPrefix(path, "["):
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
@@ -0,0 +1,183 @@
+package strudel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stevecallear/strudel"
+)
+
+func TestNewValidationError(t *testing.T) {
+	t.Run("should use status 422", func(t *testing.T) {
+		err := strudel.NewValidationError("error")
+
+		if act, exp := err.Code(), http.StatusUnprocessableEntity; act != exp {
+			t.Errorf("got %d, expected %d", act, exp)
+		}
+
+		if act := err.Message(); act != "error" {
+			t.Errorf("got %s, expected error", act)
+		}
+	})
+}
+
+func TestError_WithViolations(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *strudel.Error
+		exp    []strudel.Violation
+		fields strudel.Fields
+	}{
+		{
+			name:   "should not set the field if there are no violations",
+			err:    strudel.NewValidationError("error").WithViolations(),
+			fields: strudel.Fields{},
+		},
+		{
+			name: "should add the violations",
+			err: strudel.NewValidationError("error").
+				WithViolation("name", "required", "name is required").
+				WithViolations(strudel.Violation{Path: "age", Code: "min", Message: "age must be at least 18"}),
+			exp: []strudel.Violation{
+				{Path: "name", Code: "required", Message: "name is required"},
+				{Path: "age", Code: "min", Message: "age must be at least 18"},
+			},
+			fields: strudel.Fields{
+				"errors": []strudel.Violation{
+					{Path: "name", Code: "required", Message: "name is required"},
+					{Path: "age", Code: "min", Message: "age must be at least 18"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if act := tt.err.Violations(); !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+
+			if act := tt.err.Fields(); !reflect.DeepEqual(act, tt.fields) {
+				t.Errorf("got %v, expected %v", act, tt.fields)
+			}
+		})
+	}
+}
+
+func TestError_MergeViolations(t *testing.T) {
+	nested := strudel.NewValidationError("error").
+		WithViolation("street", "required", "street is required").
+		WithViolation("[0]", "len", "invalid length").
+		WithViolation("", "required", "address is required")
+
+	tests := []struct {
+		name   string
+		prefix string
+		err    *strudel.Error
+		exp    []strudel.Violation
+	}{
+		{
+			name: "should ignore nil errors",
+		},
+		{
+			name: "should not prefix empty prefixes",
+			err:  strudel.NewValidationError("error").WithViolation("street", "required", "street is required"),
+			exp: []strudel.Violation{
+				{Path: "street", Code: "required", Message: "street is required"},
+			},
+		},
+		{
+			name:   "should prefix the paths",
+			prefix: "address",
+			err:    nested,
+			exp: []strudel.Violation{
+				{Path: "address.street", Code: "required", Message: "street is required"},
+				{Path: "address[0]", Code: "len", Message: "invalid length"},
+				{Path: "address", Code: "required", Message: "address is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := strudel.NewValidationError("error").MergeViolations(tt.prefix, tt.err).Violations()
+
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+
+	t.Run("should not modify the merged error", func(t *testing.T) {
+		strudel.NewValidationError("error").MergeViolations("prefix", nested)
+
+		if act := nested.Violations()[0].Path; act != "street" {
+			t.Errorf("got %s, expected street", act)
+		}
+	})
+}
+
+func TestError_Violations_Encoding(t *testing.T) {
+	err := strudel.NewValidationError("invalid request").
+		WithViolation("name", "required", "name is required")
+
+	violations := []interface{}{
+		map[string]interface{}{"path": "name", "code": "required", "message": "name is required"},
+	}
+
+	tests := []struct {
+		name        string
+		encoder     strudel.ErrorEncoder
+		contentType string
+		body        map[string]interface{}
+	}{
+		{
+			name:        "should write violations to jsend data",
+			encoder:     strudel.JSend,
+			contentType: "application/json",
+			body: map[string]interface{}{
+				"status":  "fail",
+				"message": "invalid request",
+				"data":    map[string]interface{}{"errors": violations},
+			},
+		},
+		{
+			name:        "should write violations to problem errors",
+			encoder:     strudel.Problem,
+			contentType: "application/problem+json",
+			body: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  http.StatusText(http.StatusUnprocessableEntity),
+				"status": float64(http.StatusUnprocessableEntity),
+				"detail": "invalid request",
+				"errors": violations,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+			if err := tt.encoder.Encode(rec, req, err); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			assertResponse(t, rec, http.StatusUnprocessableEntity, tt.contentType, tt.body)
+		})
+	}
+
+	t.Run("should write violations to text", func(t *testing.T) {
+		rec, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+
+		if err := strudel.Text.Encode(rec, req, err); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+
+		if act, exp := rec.Body.String(), "invalid request\nname: name is required\n"; act != exp {
+			t.Errorf("got %q, expected %q", act, exp)
+		}
+	})
+}
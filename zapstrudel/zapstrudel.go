@@ -0,0 +1,44 @@
+// Package zapstrudel provides a zap log sink for strudel middleware
+package zapstrudel
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/stevecallear/strudel"
+)
+
+type sink struct {
+	logger *zap.Logger
+}
+
+// New returns a new log sink that writes to the specified zap logger
+func New(l *zap.Logger) strudel.LogSink {
+	return &sink{logger: l}
+}
+
+// Log writes the specified entry
+func (s *sink) Log(level strudel.Level, msg string, fields strudel.Fields) {
+	lvl := zapcore.InfoLevel
+	switch level {
+	case strudel.WarnLevel:
+		lvl = zapcore.WarnLevel
+	case strudel.ErrorLevel:
+		lvl = zapcore.ErrorLevel
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	zf := make([]zap.Field, len(keys))
+	for i, k := range keys {
+		zf[i] = zap.Any(k, fields[k])
+	}
+
+	s.logger.Log(lvl, msg, zf...)
+}
@@ -0,0 +1,70 @@
+package zapstrudel_test
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/stevecallear/strudel"
+	"github.com/stevecallear/strudel/zapstrudel"
+)
+
+func TestSink_Log(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  strudel.Level
+		msg    string
+		fields strudel.Fields
+		exp    zapcore.Level
+	}{
+		{
+			name:   "should write info entries",
+			level:  strudel.InfoLevel,
+			fields: strudel.Fields{"type": "request", "path": "/"},
+			exp:    zapcore.InfoLevel,
+		},
+		{
+			name:   "should write warn entries",
+			level:  strudel.WarnLevel,
+			msg:    "warning",
+			fields: strudel.Fields{"type": "recovery"},
+			exp:    zapcore.WarnLevel,
+		},
+		{
+			name:   "should write error entries",
+			level:  strudel.ErrorLevel,
+			msg:    "error",
+			fields: strudel.Fields{"type": "error", "data": map[string]interface{}{"key": "value"}},
+			exp:    zapcore.ErrorLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+
+			zapstrudel.New(zap.New(core)).Log(tt.level, tt.msg, tt.fields)
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, expected 1", len(entries))
+			}
+
+			e := entries[0]
+			if e.Level != tt.exp {
+				t.Errorf("got %v, expected %v", e.Level, tt.exp)
+			}
+
+			if e.Message != tt.msg {
+				t.Errorf("got %s, expected %s", e.Message, tt.msg)
+			}
+
+			if act := e.ContextMap(); !reflect.DeepEqual(act, map[string]interface{}(tt.fields)) {
+				t.Errorf("got %v, expected %v", act, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Package zerologstrudel provides a zerolog log sink for strudel middleware
+package zerologstrudel
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/stevecallear/strudel"
+)
+
+type sink struct {
+	logger zerolog.Logger
+}
+
+// New returns a new log sink that writes to the specified zerolog logger
+func New(l zerolog.Logger) strudel.LogSink {
+	return &sink{logger: l}
+}
+
+// Log writes the specified entry
+func (s *sink) Log(level strudel.Level, msg string, fields strudel.Fields) {
+	lvl := zerolog.InfoLevel
+	switch level {
+	case strudel.WarnLevel:
+		lvl = zerolog.WarnLevel
+	case strudel.ErrorLevel:
+		lvl = zerolog.ErrorLevel
+	}
+
+	s.logger.WithLevel(lvl).
+		Fields(map[string]interface{}(fields)).
+		Msg(msg)
+}
@@ -0,0 +1,74 @@
+package zerologstrudel_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stevecallear/strudel"
+	"github.com/stevecallear/strudel/zerologstrudel"
+)
+
+func TestSink_Log(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  strudel.Level
+		msg    string
+		fields strudel.Fields
+		exp    map[string]interface{}
+	}{
+		{
+			name:   "should write info entries",
+			level:  strudel.InfoLevel,
+			fields: strudel.Fields{"type": "request", "code": 200},
+			exp: map[string]interface{}{
+				"level": "info",
+				"type":  "request",
+				"code":  float64(200),
+			},
+		},
+		{
+			name:   "should write warn entries",
+			level:  strudel.WarnLevel,
+			msg:    "warning",
+			fields: strudel.Fields{"type": "recovery"},
+			exp: map[string]interface{}{
+				"level":   "warn",
+				"message": "warning",
+				"type":    "recovery",
+			},
+		},
+		{
+			name:   "should write error entries",
+			level:  strudel.ErrorLevel,
+			msg:    "error",
+			fields: strudel.Fields{"type": "error", "data": strudel.Fields{"key": "value"}},
+			exp: map[string]interface{}{
+				"level":   "error",
+				"message": "error",
+				"type":    "error",
+				"data":    map[string]interface{}{"key": "value"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			zerologstrudel.New(zerolog.New(buf)).Log(tt.level, tt.msg, tt.fields)
+
+			act := map[string]interface{}{}
+			if err := json.Unmarshal(buf.Bytes(), &act); err != nil {
+				t.Errorf("got %v, expected nil", err)
+			}
+
+			if !reflect.DeepEqual(act, tt.exp) {
+				t.Errorf("got %v, expected %v", act, tt.exp)
+			}
+		})
+	}
+}